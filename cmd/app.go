@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+	"github.com/bookandmusic/tool/internal/plugins"
+	builtinplugins "github.com/bookandmusic/tool/internal/plugins/builtin_plugins"
+	extraplugins "github.com/bookandmusic/tool/internal/plugins/extra_plugins"
+	"github.com/bookandmusic/tool/internal/utils"
+)
+
+// Options App 的依赖，零值字段使用当前进程的默认值，便于在测试中整体替换
+type Options struct {
+	Stdin  io.Reader // 默认 os.Stdin
+	Stdout io.Writer // 命令输出，默认 os.Stdout
+	Stderr io.Writer // 日志等诊断信息，默认 os.Stderr
+
+	Environ []string // KEY=VALUE 形式，用于 TOOL_* 覆盖、TOOL_PROFILE、TOOL_LOG_LEVEL 及插件进程的环境变量，默认 os.Environ()
+	WorkDir string   // 查找项目级 .tool.yml 的起始目录，默认当前工作目录
+
+	SystemConfigPath string // 系统级配置，默认 /etc/tool/tool.yml，"-" 表示跳过
+	UserConfigPath   string // 用户级配置，--config 优先，默认 ~/.config/tool.yml
+	CachePath        string // 插件元数据缓存，默认 ~/.cache/tool/plugins.yml
+	HostRoot         string // 探测主机信息时使用的根目录，默认 "/"
+
+	Registry *plugins.Registry // 插件注册表，为空时新建；内置插件总会注册到其中
+	Logger   logger.Logger     // 为空时根据全局 flag 创建
+	Config   *common.Config    // 不为空时直接使用，跳过配置文件和 TOOL_* 环境变量；写入操作仍修改用户级配置
+}
+
+// App 一次命令行调用的完整生命周期：解析全局 flag、加载配置、创建日志、加载插件并执行命令。
+// 配置、日志、环境变量和颜色等运行时状态保存在 App 自己的 common.Runtime 中并注入到各个服务，
+// 同一进程中的多个 App 可以并发运行；每个 App 只运行一次
+type App struct {
+	opts     Options
+	flags    globalFlags
+	registry *plugins.Registry
+	rt       *common.Runtime
+	closers  []io.Closer
+	ran      bool
+}
+
+// New 创建 App 并注册内置插件
+func New(opts Options) *App {
+	if opts.Stdin == nil {
+		opts.Stdin = os.Stdin
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+	if opts.Environ == nil {
+		opts.Environ = os.Environ()
+	}
+	if opts.WorkDir == "" {
+		opts.WorkDir, _ = os.Getwd()
+	}
+	switch opts.SystemConfigPath {
+	case "":
+		opts.SystemConfigPath = common.SystemConfigPath
+	case "-":
+		opts.SystemConfigPath = ""
+	}
+	env := common.Environ(opts.Environ)
+	if opts.UserConfigPath == "" {
+		opts.UserConfigPath = common.DefaultUserConfigPath(env)
+	}
+	if opts.CachePath == "" {
+		opts.CachePath = common.DefaultPluginCachePath(env)
+	}
+	if opts.Registry == nil {
+		opts.Registry = plugins.NewRegistry()
+	}
+	// 内置服务在注册时绑定 rt，配置和日志在 Run 中加载后填入
+	rt := &common.Runtime{
+		Stdin:     opts.Stdin,
+		Env:       env,
+		CachePath: opts.CachePath,
+		HostRoot:  opts.HostRoot,
+		Secrets:   common.NewSecrets(),
+	}
+	builtinplugins.Register(opts.Registry, rt)
+	return &App{opts: opts, registry: opts.Registry, rt: rt}
+}
+
+// unknownCommandPattern cobra 未知命令错误的格式：unknown command "xxx" for "tool"
+var unknownCommandPattern = regexp.MustCompile(`^unknown command "([^"]*)"`)
+
+// Run 执行 args（不含程序名）对应的命令
+func (a *App) Run(ctx context.Context, args []string) error {
+	if a.ran {
+		return errors.New("App.Run can only be called once")
+	}
+	a.ran = true
+	defer a.close()
+	rootCmd := newRootCmd(&a.flags)
+	rootCmd.SetArgs(args)
+	rootCmd.SetIn(a.opts.Stdin)
+	rootCmd.SetOut(a.opts.Stdout)
+	rootCmd.SetErr(a.opts.Stderr)
+
+	// 加载插件命令前需要知道全局 flag，先单独解析一遍
+	positional := a.parseGlobalFlags(rootCmd, args)
+	console := a.opts.Logger
+	if console == nil {
+		console = a.newLogger()
+	}
+	cfg, err := a.loadConfig(console)
+	if err != nil {
+		return err
+	}
+	a.loadPlugins(rootCmd, cfg, positional)
+
+	err = rootCmd.ExecuteContext(ctx)
+	// 错误信息默认不输出（SilenceErrors），未知命令需要提示用户
+	if err != nil {
+		if m := unknownCommandPattern.FindStringSubmatch(err.Error()); m != nil {
+			// 当前主机不支持的插件命令虽然隐藏，也参与建议，执行时会给出不支持的原因
+			var names []string
+			for _, c := range rootCmd.Commands() {
+				if c.Name() != "help" && c.Deprecated == "" {
+					names = append(names, c.Name())
+				}
+			}
+			suggestion := utils.DidYouMean(utils.Suggest(m[1], names, 3))
+			if suggestion == "" {
+				suggestion = "."
+			}
+			console.Error("Unknown command '%s'%s Run 'tool --help' for usage.", m[1], suggestion)
+		}
+	}
+	return err
+}
+
+// parseGlobalFlags 预解析全局 flag 并返回位置参数（插件命令之后的参数原样返回）。同时识别 -h/--help，否则遇到它们就会停止解析，
+// 之后的 -d 等全局 flag 不会生效。内置命令之后的全局 flag 同样生效；
+// 到达外部插件命令（或未知命令）后停止解析，其后的 --verbose 等同名 flag 属于插件自身
+func (a *App) parseGlobalFlags(rootCmd *cobra.Command, args []string) []string {
+	fs := pflag.NewFlagSet(rootCmd.Name(), pflag.ContinueOnError)
+	fs.AddFlagSet(rootCmd.PersistentFlags())
+	fs.BoolP("help", "h", false, "")
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	fs.SetInterspersed(false)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+
+	var positional []string
+	for {
+		_ = fs.Parse(args)
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional
+		}
+		// -- 之后全部是位置参数
+		if fs.ArgsLenAtDash() >= 0 {
+			return append(positional, rest...)
+		}
+		positional = append(positional, rest[0])
+		if !a.isBuiltinPath(positional) {
+			return append(positional, rest[1:]...)
+		}
+		args = rest[1:]
+	}
+}
+
+// isBuiltinPath 判断位置参数 path 是否仍处于内置命令中：
+// 第一个词是内置插件或 cobra 自带的 help/completion；
+// soft 命令之后不是其内置子命令的词是 soft 插件（见 plugins.LoadAll）；
+// 其余位置是内置命令的参数。此时尚未加载配置，被 allow_override 覆盖的内置命令仍按内置命令处理
+func (a *App) isBuiltinPath(path []string) bool {
+	if path[0] == "help" || path[0] == "completion" {
+		return true
+	}
+	meta := a.registry.Get(path[0])
+	if meta == nil || !meta.BuiltIn {
+		return false
+	}
+	if len(path) == 2 && meta.Name == plugins.SoftCommandName {
+		return a.registry.SoftSubcommands()[path[1]]
+	}
+	return true
+}
+
+// getenv 从注入的环境变量中读取 key
+func (a *App) getenv(key string) string {
+	return a.rt.Env.Get(key)
+}
+
+// loadConfig 加载配置：系统 -> 用户（或 --config）-> 项目 -> TOOL_* 环境变量，并填入 App 的 Runtime；
+// 注入了 Options.Config 时直接使用。指定的 profile 不存在时返回错误，避免以错误的配置继续执行
+func (a *App) loadConfig(console logger.Logger) (*common.Config, error) {
+	configLog := console.With(logger.KeyComponent, "config")
+	configPath := a.flags.configPath
+	if configPath == "" {
+		configPath = a.opts.UserConfigPath
+	}
+	var layered *common.LayeredConfig
+	if a.opts.Config != nil {
+		layered = common.NewLayeredConfig(a.opts.Config)
+		configLog.Debug("Using injected configuration")
+	} else {
+		// 旧版本配置只在内存中升级，由 config migrate 或修改配置的命令写回
+		layered = common.LoadLayeredConfig(common.LayerOptions{
+			SystemPath: a.opts.SystemConfigPath,
+			UserPath:   configPath,
+			WorkDir:    a.opts.WorkDir,
+			Environ:    a.rt.Env,
+		})
+	}
+	for _, layer := range layered.Layers {
+		if layer.Err != nil {
+			configLog.Error("Failed to parse %s config file: %s, error: %v", layer.Name, layer.Path, layer.Err)
+		} else {
+			configLog.Debug("Loaded %s configuration from %s", layer.Name, layer.Path)
+		}
+	}
+	// 配置文件存在但解析失败时上面已经输出了错误，不再提示找不到配置文件
+	if !layered.Found() && a.opts.Config == nil {
+		configLog.Warning("Config file not found, using default configuration. Run `tool init config [cfg-path]` to generate default config.")
+	}
+	// profile 只叠加到内存中的配置，不会写回配置文件
+	profile := a.flags.profile
+	if profile == "" {
+		profile = a.getenv(common.EnvProfile)
+	}
+	if profile != "" {
+		if err := layered.ApplyProfile(profile); err != nil {
+			configLog.Error("Failed to apply profile: %v", err)
+			return nil, err
+		}
+		configLog.Debug("Applied profile '%s'", profile)
+	}
+	rt := a.rt
+	rt.Cfg = layered.Cfg
+	rt.Logger = console
+	rt.CfgPath = configPath
+	rt.Layered = layered
+	rt.Output = a.flags.output
+	rt.Columns = a.flags.columns
+	rt.NoCache = a.flags.noCache
+	return layered.Cfg, nil
+}
+
+// loadPlugins 发现外部插件并把注册表中的插件挂到根命令上
+func (a *App) loadPlugins(rootCmd *cobra.Command, cfg *common.Config, positional []string) {
+	console := a.rt.Logger
+	pluginLog := console.With(logger.KeyComponent, "plugin")
+	if needsExtraPlugins(positional, cfg) {
+		var cache *extraplugins.MetaCache
+		if !a.flags.noCache {
+			cache = extraplugins.OpenCache(a.opts.CachePath)
+		}
+		_ = extraplugins.LoadAllExtraPluginMeta(a.rt, a.registry, cache)
+	} else {
+		pluginLog.Trace("Skipping extra plugin discovery")
+	}
+	for _, meta := range a.lazyTargets(positional) {
+		if err := meta.Load(); err != nil {
+			pluginLog.Warning("Skipping %s due to load error: %v", meta.Dir, err)
+			if a.registry.Get(meta.Name) == meta {
+				a.registry.Unregister(meta.Name)
+				a.registry.RegisterFailure(plugins.LoadFailure{Name: meta.Name, Dir: meta.Dir, Err: err})
+			}
+		}
+	}
+	if err := plugins.LoadAll(rootCmd, a.registry, console); err != nil {
+		pluginLog.Error("Failed to load plugins: %v", err)
+	} else {
+		pluginLog.Debug("All plugins loaded successfully")
+	}
+}
+
+// close 关闭运行期间打开的文件（如 --log-file）
+func (a *App) close() {
+	for _, c := range a.closers {
+		_ = c.Close()
+	}
+	a.closers = nil
+}
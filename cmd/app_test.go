@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bookandmusic/tool/internal/common"
+)
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		verbose    int
+		quiet      bool
+		output     string
+	}{
+		{name: "no args", args: nil, output: "table"},
+		{name: "before command", args: []string{"-v", "info"}, positional: []string{"info"}, verbose: 1, output: "table"},
+		{name: "after builtin command", args: []string{"plugin", "ls", "-vv", "-o", "json"}, positional: []string{"plugin", "ls"}, verbose: 2, output: "json"},
+		{name: "builtin command arguments", args: []string{"plugin", "info", "docker", "-q"}, positional: []string{"plugin", "info", "docker"}, quiet: true, output: "table"},
+		{name: "soft builtin subcommand", args: []string{"soft", "install", "-v"}, positional: []string{"soft", "install"}, verbose: 1, output: "table"},
+		{name: "plugin command keeps its flags", args: []string{"info", "--verbose", "-o", "x"}, positional: []string{"info", "--verbose", "-o", "x"}, output: "table"},
+		{name: "soft plugin keeps its flags", args: []string{"-q", "soft", "docker", "install", "--verbose"}, positional: []string{"soft", "docker", "install", "--verbose"}, quiet: true, output: "table"},
+		{name: "subcommand flags skipped", args: []string{"plugin", "ls", "--type", "soft", "-v", "--builtin=false"}, positional: []string{"plugin", "ls"}, verbose: 1, output: "table"},
+		{name: "double dash", args: []string{"-v", "--", "plugin", "-q"}, positional: []string{"plugin", "-q"}, verbose: 1, output: "table"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(Options{})
+			rootCmd := newRootCmd(&a.flags)
+			positional := a.parseGlobalFlags(rootCmd, tt.args)
+			if len(positional) != 0 || len(tt.positional) != 0 {
+				if !reflect.DeepEqual(positional, tt.positional) {
+					t.Errorf("positional = %q, want %q", positional, tt.positional)
+				}
+			}
+			if a.flags.verbose != tt.verbose || a.flags.quiet != tt.quiet || a.flags.output != tt.output {
+				t.Errorf("flags = %+v, want verbose=%d quiet=%v output=%s", a.flags, tt.verbose, tt.quiet, tt.output)
+			}
+		})
+	}
+}
+
+func TestLazyTargets(t *testing.T) {
+	a := New(Options{})
+	a.registry.MustRegister(&common.Meta{Name: "info", Type: common.Command})
+	a.registry.MustRegister(&common.Meta{Name: "docker", Type: common.Soft})
+
+	all := []string{}
+	for _, m := range a.registry.List() {
+		all = append(all, m.Name)
+	}
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{"help"}, nil},
+		{[]string{"nope"}, nil},
+		{[]string{"info", "--verbose"}, []string{"info"}},
+		{[]string{"help", "info"}, []string{"info"}},
+		{[]string{"__complete", "info", "--"}, []string{"info"}},
+		{[]string{"soft"}, nil},
+		{[]string{"soft", "docker", "install"}, []string{"docker"}},
+		{[]string{"__complete", "soft", "docker", ""}, []string{"docker"}},
+		{[]string{"soft", "nope"}, nil},
+		{[]string{"soft", "install"}, all},
+		{[]string{"plugin", "ls"}, all},
+		{[]string{"config", "validate"}, all},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, m := range a.lazyTargets(tt.args) {
+			got = append(got, m.Name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lazyTargets(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+const helloManifest = `name: hello
+type: command
+exec: hello.sh
+exec_type: shell
+desc: Say hello
+flags:
+  - name: verbose
+    default: false
+  - name: who
+    default: world
+`
+
+const helloScript = `read line
+echo "args: $*"
+echo "greeting: $GREETING"
+echo "stdin: $line"
+`
+
+// e2eEnv 端到端测试的临时环境，插件目录、配置和缓存都位于 dir 下
+type e2eEnv struct {
+	dir       string
+	pluginDir string
+	cfgPath   string
+	cachePath string
+	environ   []string
+	config    *common.Config // 不为空时注入 Options.Config
+}
+
+func newE2EEnv(t *testing.T, config string) *e2eEnv {
+	t.Helper()
+	dir := t.TempDir()
+	e := &e2eEnv{
+		dir:       dir,
+		pluginDir: filepath.Join(dir, "plugins"),
+		cfgPath:   filepath.Join(dir, "tool.yml"),
+		cachePath: filepath.Join(dir, "cache", "plugins.yml"),
+		environ:   []string{"PATH=" + os.Getenv("PATH"), "GREETING=hi"},
+	}
+	writeFile(t, filepath.Join(e.pluginDir, "hello", "meta.yml"), helloManifest)
+	writeFile(t, filepath.Join(e.pluginDir, "hello", "hello.sh"), helloScript)
+	writeFile(t, e.cfgPath, strings.ReplaceAll(config, "$PLUGINS", e.pluginDir))
+	return e
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// run 以注入的标准输入输出和环境变量运行 App
+func (e *e2eEnv) run(stdin string, args ...string) (stdout, stderr string, err error) {
+	var out, diag bytes.Buffer
+	app := New(Options{
+		Stdin:            strings.NewReader(stdin),
+		Stdout:           &out,
+		Stderr:           &diag,
+		Environ:          e.environ,
+		WorkDir:          e.dir,
+		SystemConfigPath: "-",
+		UserConfigPath:   e.cfgPath,
+		CachePath:        e.cachePath,
+		HostRoot:         e.dir,
+		Config:           e.config,
+	})
+	err = app.Run(context.Background(), args)
+	return out.String(), diag.String(), err
+}
+
+const e2eConfig = `plugin_dirs: [$PLUGINS]
+executor:
+  shell: /bin/sh
+`
+
+func TestAppRunPlugin(t *testing.T) {
+	e := newE2EEnv(t, e2eConfig)
+
+	stdout, stderr, err := e.run("from stdin\n", "hello", "--verbose", "--who", "bob")
+	if err != nil {
+		t.Fatalf("Run error: %v\nstderr: %s", err, stderr)
+	}
+	for _, want := range []string{"args: --verbose --who=bob\n", "greeting: hi\n", "stdin: from stdin\n"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	}
+	// --verbose 属于插件，不会打开工具自身的调试日志
+	if strings.Contains(stderr, "Executing command") {
+		t.Errorf("stderr = %q, plugin --verbose was taken as the global flag", stderr)
+	}
+}
+
+func TestAppRunPluginDirsFromEnviron(t *testing.T) {
+	e := newE2EEnv(t, "executor:\n  shell: /bin/sh\n")
+	e.environ = append(e.environ, "TOOL_PLUGIN_DIRS="+e.pluginDir)
+
+	stdout, stderr, err := e.run("", "hello")
+	if err != nil {
+		t.Fatalf("Run error: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "args: --who=world\n") {
+		t.Errorf("stdout = %q, want plugin output with default flags", stdout)
+	}
+}
+
+func TestAppRunUnknownProfile(t *testing.T) {
+	e := newE2EEnv(t, e2eConfig)
+
+	stdout, stderr, err := e.run("", "--profile", "nope", "hello")
+	if err == nil {
+		t.Fatal("Run succeeded with an unknown profile")
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, plugin ran with an unknown profile", stdout)
+	}
+	if !strings.Contains(stderr, "nope") {
+		t.Errorf("stderr = %q, want the unknown profile reported", stderr)
+	}
+}
+
+func TestAppRunUnknownCommand(t *testing.T) {
+	e := newE2EEnv(t, e2eConfig)
+
+	_, stderr, err := e.run("", "helo")
+	if err == nil {
+		t.Fatal("Run succeeded with an unknown command")
+	}
+	if !strings.Contains(stderr, "Unknown command 'helo'") || !strings.Contains(stderr, "hello") {
+		t.Errorf("stderr = %q, want unknown command with suggestion", stderr)
+	}
+}
+
+func TestAppRunNoCache(t *testing.T) {
+	e := newE2EEnv(t, e2eConfig)
+
+	if _, stderr, err := e.run("", "--no-cache", "hello"); err != nil {
+		t.Fatalf("Run error: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(e.cachePath); !os.IsNotExist(err) {
+		t.Errorf("cache written with --no-cache: %v", err)
+	}
+	if _, stderr, err := e.run("", "hello"); err != nil {
+		t.Fatalf("Run error: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(e.cachePath); err != nil {
+		t.Errorf("cache not written: %v", err)
+	}
+}
+
+func TestAppRunOnce(t *testing.T) {
+	e := newE2EEnv(t, e2eConfig)
+	app := New(Options{Stdout: io.Discard, Stderr: io.Discard, Environ: e.environ, WorkDir: e.dir,
+		SystemConfigPath: "-", UserConfigPath: e.cfgPath, CachePath: e.cachePath})
+
+	if err := app.Run(context.Background(), []string{"version"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Run(context.Background(), []string{"version"}); err == nil {
+		t.Error("second Run of the same App succeeded")
+	}
+}
+
+func TestAppRunConcurrent(t *testing.T) {
+	fromFile := newE2EEnv(t, e2eConfig)
+	fromFile.environ = append(fromFile.environ, "GREETING=from-file")
+
+	// 注入的配置不读取配置文件，插件目录只在注入的配置中声明
+	injected := newE2EEnv(t, "plugin_dirs: [/nonexistent]\n")
+	injected.environ = append(injected.environ, "GREETING=injected")
+	writeFile(t, filepath.Join(injected.pluginDir, "hello", "hello.sh"), "echo \"injected greeting: $GREETING\"\n")
+	injected.config = &common.Config{
+		Version:    common.CurrentConfigVersion,
+		PluginDirs: []string{injected.pluginDir},
+		Executor:   &common.Executor{Shell: "/bin/sh"},
+	}
+
+	envs := []*e2eEnv{fromFile, injected}
+	want := []string{"greeting: from-file\n", "injected greeting: injected\n"}
+	stdouts := make([]string, len(envs))
+	errs := make([]error, len(envs))
+	var wg sync.WaitGroup
+	for i, e := range envs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var stderr string
+			stdouts[i], stderr, errs[i] = e.run("from stdin\n", "hello")
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("%w\nstderr: %s", errs[i], stderr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range envs {
+		if errs[i] != nil {
+			t.Errorf("app %d: Run error: %v", i, errs[i])
+			continue
+		}
+		if !strings.Contains(stdouts[i], want[i]) {
+			t.Errorf("app %d: stdout = %q, want %q", i, stdouts[i], want[i])
+		}
+	}
+}
@@ -1,71 +1,172 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bookandmusic/tool/internal/common"
 	"github.com/bookandmusic/tool/internal/logger"
 	"github.com/bookandmusic/tool/internal/plugins"
-	_ "github.com/bookandmusic/tool/internal/plugins/builtin_plugins"
-	extraplugins "github.com/bookandmusic/tool/internal/plugins/extra_plugins"
 )
 
-var (
+// globalFlags 根命令上的全局 flag
+type globalFlags struct {
 	configPath string
+	profile    string
 	debug      bool
-	cfg        *common.Config
-	console    logger.Logger
-)
+	verbose    int
+	quiet      bool
+	logFormat  string
+	logFile    string
+	colorMode  string
+	output     string
+	columns    []string
+	noCache    bool
+}
 
-var rootCmd = &cobra.Command{
-	Use:           "tool",
-	Short:         "tool with plugin system",
-	SilenceUsage:  true,
-	SilenceErrors: true,
+// newRootCmd 创建根命令并把全局 flag 绑定到 f
+func newRootCmd(f *globalFlags) *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:           "tool",
+		Short:         "tool with plugin system",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		// 使用与插件名称一致的建议算法，见 App.Run
+		DisableSuggestions: true,
+	}
+	rootCmd.PersistentFlags().StringVarP(&f.configPath, "config", "c", "", "Path to config file")
+	rootCmd.PersistentFlags().BoolVarP(&f.debug, "debug", "d", false, "enable debug mode (same as -v)")
+	rootCmd.PersistentFlags().CountVarP(&f.verbose, "verbose", "v", "Increase verbosity: -v for debug, -vv for trace (default $"+logger.EnvLogLevel+" or info)")
+	rootCmd.PersistentFlags().BoolVarP(&f.quiet, "quiet", "q", false, "Only print errors")
+	rootCmd.PersistentFlags().StringVar(&f.logFormat, "log-format", logger.FormatText, "Log format: text, json or logfmt")
+	rootCmd.PersistentFlags().StringVar(&f.logFile, "log-file", "", "Also write logs to this file")
+	rootCmd.PersistentFlags().StringVar(&f.colorMode, "color", logger.ColorAuto, "Colorize output: auto, always or never (auto honors NO_COLOR and non-TTY output)")
+	rootCmd.PersistentFlags().StringVarP(&f.output, "output", "o", common.OutputTable, "Output format of listing commands: "+strings.Join(common.OutputFormats, ", "))
+	rootCmd.PersistentFlags().StringSliceVar(&f.columns, "columns", nil, "Comma-separated columns to show in listing commands")
+	rootCmd.PersistentFlags().BoolVar(&f.noCache, "no-cache", false, "Do not read or write the plugin metadata cache")
+	rootCmd.PersistentFlags().StringVar(&f.profile, "profile", "", "Config profile to apply (default $"+common.EnvProfile+")")
+	return rootCmd
 }
 
-func Execute() error { return rootCmd.Execute() }
+// Execute 使用进程的参数、环境变量和标准流运行 tool
+func Execute(ctx context.Context) error {
+	return New(Options{}).Run(ctx, os.Args[1:])
+}
 
-func init() {
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to config file")
-	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "enable debug mode")
+// standaloneCommands 不依赖外部插件的内置命令，执行时跳过插件发现
+var standaloneCommands = map[string]bool{"init": true, "version": true, "schema": true}
 
-	_ = rootCmd.PersistentFlags().Parse(os.Args)
-	console = logger.NewConsoleLogger(os.Stdout, debug)
-	// 提前加载配置和插件命令
-	if configPath == "" {
-		home, _ := os.UserHomeDir()
-		configPath = filepath.Join(home, ".config", "tool.yml")
+// needsExtraPlugins 判断本次调用是否需要加载外部插件。args 为预解析后的位置参数，
+// 帮助、补全和插件命令都需要完整的插件列表；被 allow_override 覆盖的内置命令同样需要加载
+func needsExtraPlugins(args []string, cfg *common.Config) bool {
+	if len(args) == 0 {
+		return true
 	}
+	name := args[0]
+	return !standaloneCommands[name] || slices.Contains(cfg.AllowOverride, name)
+}
 
-	if _, err := os.Stat(configPath); err == nil {
-		cfg, err = common.LoadConfig(configPath)
-		if err != nil {
-			console.Debug(fmt.Sprintf("[CONFIG] Failed to parse config file: %s, error: %v\n", configPath, err))
-		} else {
-			console.Debug(fmt.Sprintf("[CONFIG] Loaded configuration from %s\n", configPath))
+// helpCommands cobra 自带的帮助和补全命令，其后的位置参数是目标命令
+var helpCommands = map[string]bool{
+	"help":                          true,
+	cobra.ShellCompRequestCmd:       true,
+	cobra.ShellCompNoDescRequestCmd: true,
+}
+
+// lazyTargets 返回本次调用需要完整加载的插件（见 common.Meta.Load）：
+// 执行外部插件或查看它的帮助、补全时只加载该插件；执行内置命令时全部加载，
+// 内置命令（plugin ls、soft install 等）需要完整的 flags 和子命令；只列出名称和描述的帮助不加载
+func (a *App) lazyTargets(args []string) []*common.Meta {
+	for len(args) > 0 && helpCommands[args[0]] {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	meta := a.registry.Get(args[0])
+	switch {
+	case meta == nil:
+		return nil
+	case !meta.BuiltIn:
+		return []*common.Meta{meta}
+	case meta.Name == plugins.SoftCommandName && len(args) == 1:
+		return nil
+	case meta.Name == plugins.SoftCommandName && !a.registry.SoftSubcommands()[args[1]]:
+		if m := a.registry.Get(args[1]); m != nil && m.Type == common.Soft {
+			return []*common.Meta{m}
 		}
-	} else {
-		console.Debug(fmt.Sprintf("[CONFIG] Config file does not exist: %s\n", configPath))
+		return nil
 	}
+	metas := a.registry.List()
+	for _, shadow := range a.registry.Shadowed() {
+		metas = append(metas, shadow.Meta)
+	}
+	return metas
+}
 
-	if cfg == nil {
-		cfg = common.GenerateDefault()
-		console.Warning("[CONFIG] Config file not found, using default configuration. Run `tool init config [cfg-path]` to generate default config.")
+// logLevel 计算日志级别：-q/-v/-d 优先，其次 TOOL_LOG_LEVEL，默认 info
+func (a *App) logLevel() (logger.Level, error) {
+	f := &a.flags
+	if f.debug && f.verbose == 0 {
+		f.verbose = 1
+	}
+	if f.quiet || f.verbose > 0 {
+		return logger.VerbosityLevel(f.verbose, f.quiet), nil
 	}
-	common.GlobalCfg = &common.GlobalConfig{
-		Cfg:     cfg,
-		CfgPath: configPath,
-		Logger:  console,
+	if env := a.getenv(logger.EnvLogLevel); env != "" {
+		return logger.ParseLevel(env)
 	}
-	_ = extraplugins.LoadAllExtraPluginMeta(cfg)
-	if err := plugins.LoadAll(rootCmd); err != nil {
-		console.Error("[PLUGIN] Failed to load plugins: %v", err)
-	} else {
-		console.Debug("[PLUGIN] All plugins loaded successfully\n")
+	return logger.LevelInfo, nil
+}
+
+// newLogger 根据 --log-format 和 --log-file 创建 Logger，参数错误时回退到文本格式并提示
+func (a *App) newLogger() logger.Logger {
+	f := &a.flags
+	var warnings []string
+	level, err := a.logLevel()
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	// 日志写入 stderr，表格等结果写入 stdout，两者分别判断是否为终端
+	lookupEnv := a.rt.Env.Lookup
+	logColor, err := logger.ColorEnabled(f.colorMode, a.opts.Stderr, lookupEnv)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+		f.colorMode = logger.ColorAuto
+		logColor, _ = logger.ColorEnabled(f.colorMode, a.opts.Stderr, lookupEnv)
+	}
+	a.rt.Color, _ = logger.ColorEnabled(f.colorMode, a.opts.Stdout, lookupEnv)
+
+	formatter, err := logger.NewFormatter(f.logFormat, logColor)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+		formatter = &logger.TextFormatter{Color: logColor}
+	}
+	loggers := []logger.Logger{logger.NewLogger(a.opts.Stdout, a.opts.Stderr, level, formatter)}
+
+	if f.logFile != "" {
+		// 文件中不输出颜色
+		fileFormatter, _ := logger.NewFormatter(f.logFormat, false)
+		if fileFormatter == nil {
+			fileFormatter = &logger.TextFormatter{}
+		}
+		file, err := os.OpenFile(f.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) // #nosec G304
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to open log file: %v", err))
+		} else {
+			a.closers = append(a.closers, file)
+			loggers = append(loggers, logger.NewLogger(file, file, level, fileFormatter))
+		}
+	}
+
+	l := logger.NewMultiLogger(loggers...)
+	for _, w := range warnings {
+		l.Warning("%s", w)
 	}
+	return l
 }
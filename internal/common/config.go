@@ -8,20 +8,48 @@ import (
 )
 
 type PluginConfig struct {
-	Install   map[string]any `yaml:"install,omitempty"`
-	Uninstall map[string]any `yaml:"uninstall,omitempty"`
+	Install   map[string]any `yaml:"install,omitempty" desc:"Flags passed to the install command"`
+	Uninstall map[string]any `yaml:"uninstall,omitempty" desc:"Flags passed to the uninstall command"`
+	// Defaults 记录 soft enable/sync 时 meta.yml 中的默认值，soft sync 据此判断哪些值被用户修改过
+	Defaults *PluginDefaults `yaml:"defaults,omitempty" desc:"meta.yml defaults recorded by soft enable/sync, do not edit"`
+}
+
+type PluginDefaults struct {
+	Install   map[string]any `yaml:"install,omitempty" desc:"Defaults of the install command flags"`
+	Uninstall map[string]any `yaml:"uninstall,omitempty" desc:"Defaults of the uninstall command flags"`
 }
 
 type Executor struct {
-	Shell  string `yaml:"shell"`
-	Python string `yaml:"python"`
+	Shell  string `yaml:"shell,omitempty" desc:"Interpreter for exec_type: shell"`
+	Python string `yaml:"python,omitempty" desc:"Interpreter for exec_type: python"`
+}
+
+// Profile 针对某类机器（开发机、CI、构建服务器等）的配置，在基础配置之上叠加
+type Profile struct {
+	Desc           string                  `yaml:"desc,omitempty" desc:"Profile description"`
+	EnabledPlugins []string                `yaml:"enabled_plugins,omitempty" desc:"Soft plugins enabled in addition to the base enabled_plugins"`
+	Plugins        map[string]PluginConfig `yaml:"plugins,omitempty" desc:"Per-plugin flag overrides applied on top of the base plugins"`
+}
+
+// InlinePlugin 直接定义在配置文件中的 command 插件，run 通过 executor.shell -c 执行
+type InlinePlugin struct {
+	Name  string         `yaml:"name" schema:"required" desc:"Plugin name, used as the command name"`
+	Desc  string         `yaml:"desc,omitempty" desc:"Short description shown in help"`
+	Flags []*CommandFlag `yaml:"flags,omitempty" desc:"Flags passed to the script as --name=value arguments"`
+	Run   string         `yaml:"run" schema:"required" desc:"Shell script run with executor.shell -c, flags and arguments are available as \"$@\""`
+	Dir   string         `yaml:"-"` // 声明该插件的配置文件所在目录，作为脚本的工作目录
 }
 
 type Config struct {
-	PluginDirs     []string                `yaml:"plugin_dirs"`
-	EnabledPlugins []string                `yaml:"enabled_plugins"`
-	Plugins        map[string]PluginConfig `yaml:"plugins"`
-	Executor       *Executor               `yaml:"executor"`
+	Version        int                     `yaml:"version" desc:"Config schema version, upgraded automatically by tool"`
+	PluginDirs     []string                `yaml:"plugin_dirs" desc:"Directories searched for extra plugins; ~ and glob patterns are expanded, relative paths are resolved against this file"`
+	PluginDepth    int                     `yaml:"plugin_depth,omitempty" desc:"How many directory levels below each plugin dir are searched, default 1"`
+	EnabledPlugins []string                `yaml:"enabled_plugins" desc:"Soft plugins handled by soft install/destroy"`
+	Plugins        map[string]PluginConfig `yaml:"plugins" desc:"Per-plugin install/uninstall flags"`
+	AllowOverride  []string                `yaml:"allow_override,omitempty" desc:"Builtin plugins that extra plugins with the same name may replace"`
+	Executor       *Executor               `yaml:"executor,omitempty" desc:"Interpreters used to run plugin scripts"`
+	InlinePlugins  []InlinePlugin          `yaml:"inline_plugins,omitempty" desc:"Command plugins defined in the config file instead of a plugin directory"`
+	Profiles       map[string]Profile      `yaml:"profiles,omitempty" desc:"Named profiles selected with --profile or TOOL_PROFILE"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -31,32 +59,52 @@ func LoadConfig(path string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	// 旧版本配置先在内存中迁移，写回由 MigrateConfigFile 负责
+	if data, _, err = MigrateConfigData(data); err != nil {
+		return nil, err
+	}
+	if err := ConfigSchema().ValidateYAML(data); err != nil {
+		return nil, err
+	}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
 	return &cfg, nil
 }
 
-func SaveConfig(path string, cfg *Config) error {
-	data, err := yaml.Marshal(cfg)
-	if err != nil {
-		return err
+// UpdateConfig 读取 path 指向的单个配置文件（不存在时使用基于 env 的默认配置），
+// 交给 fn 修改后写回。用于只修改某一层配置，避免把合并后的配置写入用户文件
+func UpdateConfig(path string, env Environ, fn func(cfg *Config) error) error {
+	cfg := GenerateDefault(env)
+	if _, err := os.Stat(path); err == nil {
+		if cfg, err = LoadConfig(path); err != nil {
+			return err
+		}
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+	if cfg.Plugins == nil {
+		cfg.Plugins = map[string]PluginConfig{}
+	}
+	if err := fn(cfg); err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0o600)
+	return SaveConfig(path, cfg)
 }
 
-func GenerateDefault() *Config {
+// GenerateDefault 生成默认配置，默认插件目录基于 env 计算
+func GenerateDefault(env Environ) *Config {
 	defaultCfg := &Config{
-		PluginDirs:     []string{"./plugins"},
+		Version:        CurrentConfigVersion,
+		PluginDirs:     []string{DefaultPluginDir(env)},
 		EnabledPlugins: []string{},
 		Plugins:        map[string]PluginConfig{},
-		Executor: &Executor{
-			Python: "/usr/bin/python3",
-			Shell:  "/bin/bash",
-		},
+		Executor:       defaultExecutor(),
 	}
 	return defaultCfg
 }
+
+func defaultExecutor() *Executor {
+	return &Executor{
+		Python: "/usr/bin/python3",
+		Shell:  "/bin/bash",
+	}
+}
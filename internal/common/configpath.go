@@ -0,0 +1,219 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// PathSegment 配置路径中的一段：映射键或列表下标
+type PathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+func (s PathSegment) String() string {
+	if s.IsIndex {
+		return fmt.Sprintf("[%d]", s.Index)
+	}
+	return s.Key
+}
+
+// ParseConfigPath 解析形如 plugins.docker.install.work-dir 或 plugin_dirs[0] 的路径
+func ParseConfigPath(path string) ([]PathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty config path")
+	}
+	var segs []PathSegment
+	for _, part := range strings.Split(path, ".") {
+		key, rest, indexed := strings.Cut(part, "[")
+		// 空段只允许出现在下标之前，如 a.[0]；路径不能以下标或 . 开头
+		if key == "" && (len(segs) == 0 || !indexed) {
+			return nil, fmt.Errorf("invalid config path %q", path)
+		}
+		if key != "" {
+			segs = append(segs, PathSegment{Key: key})
+		}
+		for indexed {
+			idx, after, ok := strings.Cut(rest, "]")
+			n, err := strconv.Atoi(idx)
+			if !ok || err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid index in config path %q", path)
+			}
+			segs = append(segs, PathSegment{Index: n, IsIndex: true})
+			if after == "" {
+				break
+			}
+			if rest, indexed = strings.CutPrefix(after, "["); !indexed {
+				return nil, fmt.Errorf("invalid config path %q", path)
+			}
+		}
+	}
+	return segs, nil
+}
+
+// configTree 把配置转换为 map/slice 组成的通用树，便于按路径读写
+func configTree(cfg *Config) (map[string]any, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tree := map[string]any{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// applyTree 校验通用树并写回配置
+func applyTree(cfg *Config, tree map[string]any) error {
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	if err := ConfigSchema().ValidateYAML(data); err != nil {
+		return err
+	}
+	var updated Config
+	if err := yaml.Unmarshal(data, &updated); err != nil {
+		return err
+	}
+	*cfg = updated
+	return nil
+}
+
+// GetConfigValue 读取路径对应的值，不存在时返回错误
+func GetConfigValue(cfg *Config, path string) (any, error) {
+	segs, err := ParseConfigPath(path)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := configTree(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var cur any = tree
+	for _, seg := range segs {
+		next, ok := child(cur, seg)
+		if !ok {
+			return nil, fmt.Errorf("config key %q not found", path)
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+func child(node any, seg PathSegment) (any, bool) {
+	if seg.IsIndex {
+		list, ok := node.([]any)
+		if !ok || seg.Index >= len(list) {
+			return nil, false
+		}
+		return list[seg.Index], true
+	}
+	m, ok := node.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	v, ok := m[seg.Key]
+	return v, ok
+}
+
+// SetConfigValue 设置路径对应的值，中间缺失的映射会自动创建，
+// 列表下标等于列表长度时追加元素
+func SetConfigValue(cfg *Config, path string, value any) error {
+	segs, err := ParseConfigPath(path)
+	if err != nil {
+		return err
+	}
+	tree, err := configTree(cfg)
+	if err != nil {
+		return err
+	}
+	updated, err := setIn(tree, segs, value, path)
+	if err != nil {
+		return err
+	}
+	return applyTree(cfg, updated.(map[string]any))
+}
+
+func setIn(node any, segs []PathSegment, value any, path string) (any, error) {
+	if len(segs) == 0 {
+		return value, nil
+	}
+	seg := segs[0]
+	if seg.IsIndex {
+		list, _ := node.([]any)
+		if node != nil && list == nil {
+			return nil, fmt.Errorf("config key %q is not a list", path)
+		}
+		switch {
+		case seg.Index < len(list):
+			v, err := setIn(list[seg.Index], segs[1:], value, path)
+			if err != nil {
+				return nil, err
+			}
+			list[seg.Index] = v
+		case seg.Index == len(list):
+			v, err := setIn(nil, segs[1:], value, path)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		default:
+			return nil, fmt.Errorf("index %d out of range in %q", seg.Index, path)
+		}
+		return list, nil
+	}
+
+	m, _ := node.(map[string]any)
+	if m == nil {
+		if node != nil {
+			return nil, fmt.Errorf("config key %q is not a mapping", path)
+		}
+		m = map[string]any{}
+	}
+	v, err := setIn(m[seg.Key], segs[1:], value, path)
+	if err != nil {
+		return nil, err
+	}
+	m[seg.Key] = v
+	return m, nil
+}
+
+// UnsetConfigValue 删除路径对应的键或列表元素，不存在时返回错误
+func UnsetConfigValue(cfg *Config, path string) error {
+	segs, err := ParseConfigPath(path)
+	if err != nil {
+		return err
+	}
+	tree, err := configTree(cfg)
+	if err != nil {
+		return err
+	}
+	parentSegs, last := segs[:len(segs)-1], segs[len(segs)-1]
+	var parent any = tree
+	for _, seg := range parentSegs {
+		next, ok := child(parent, seg)
+		if !ok {
+			return fmt.Errorf("config key %q not found", path)
+		}
+		parent = next
+	}
+	if _, ok := child(parent, last); !ok {
+		return fmt.Errorf("config key %q not found", path)
+	}
+	if last.IsIndex {
+		list := parent.([]any)
+		list = append(list[:last.Index], list[last.Index+1:]...)
+		if _, err := setIn(tree, parentSegs, list, path); err != nil {
+			return err
+		}
+	} else {
+		delete(parent.(map[string]any), last.Key)
+	}
+	return applyTree(cfg, tree)
+}
@@ -0,0 +1,170 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigPath(t *testing.T) {
+	key := func(k string) PathSegment { return PathSegment{Key: k} }
+	idx := func(i int) PathSegment { return PathSegment{Index: i, IsIndex: true} }
+	tests := []struct {
+		path string
+		want []PathSegment
+	}{
+		{"version", []PathSegment{key("version")}},
+		{"plugins.docker.install.work-dir", []PathSegment{key("plugins"), key("docker"), key("install"), key("work-dir")}},
+		{"plugin_dirs[0]", []PathSegment{key("plugin_dirs"), idx(0)}},
+		{"inline_plugins[1].flags[0].name", []PathSegment{key("inline_plugins"), idx(1), key("flags"), idx(0), key("name")}},
+		{"a[0][2]", []PathSegment{key("a"), idx(0), idx(2)}},
+		{"a.[1]", []PathSegment{key("a"), idx(1)}},
+	}
+	for _, tt := range tests {
+		got, err := ParseConfigPath(tt.path)
+		if err != nil {
+			t.Errorf("ParseConfigPath(%q): %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseConfigPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"", ".version", "[0]", "a[", "a[x]", "a[-1]", "a[0]b", "a..b", "a."} {
+		if segs, err := ParseConfigPath(path); err == nil {
+			t.Errorf("ParseConfigPath(%q) = %v, want error", path, segs)
+		}
+	}
+}
+
+func testConfig() *Config {
+	return &Config{
+		Version:        CurrentConfigVersion,
+		PluginDirs:     []string{"/a", "/b"},
+		EnabledPlugins: []string{"docker"},
+		Plugins: map[string]PluginConfig{
+			"docker": {Install: map[string]any{"work-dir": "/tmp/docker"}},
+		},
+		InlinePlugins: []InlinePlugin{{Name: "hi", Run: "echo hi"}},
+	}
+}
+
+func TestGetConfigValue(t *testing.T) {
+	cfg := testConfig()
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"version", CurrentConfigVersion},
+		{"plugin_dirs[1]", "/b"},
+		{"plugins.docker.install.work-dir", "/tmp/docker"},
+		{"inline_plugins[0].run", "echo hi"},
+		{"plugin_dirs", []any{"/a", "/b"}},
+	}
+	for _, tt := range tests {
+		got, err := GetConfigValue(cfg, tt.path)
+		if err != nil {
+			t.Errorf("GetConfigValue(%q): %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetConfigValue(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"missing", "plugin_dirs[2]", "plugins.nginx", "version.major", "plugins[0]"} {
+		if _, err := GetConfigValue(cfg, path); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("GetConfigValue(%q) error = %v, want not found", path, err)
+		}
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	cfg := testConfig()
+	sets := []struct {
+		path  string
+		value any
+	}{
+		{"plugin_dirs[0]", "/c"},
+		{"plugin_dirs[2]", "/d"}, // 下标等于长度时追加
+		{"plugins.docker.install.work-dir", "/opt/docker"},
+		{"plugins.nginx.install.port", 8080}, // 自动创建中间映射
+		{"executor.shell", "/bin/zsh"},
+		{"inline_plugins[0].desc", "Say hi"},
+	}
+	for _, s := range sets {
+		if err := SetConfigValue(cfg, s.path, s.value); err != nil {
+			t.Fatalf("SetConfigValue(%q): %v", s.path, err)
+		}
+	}
+	if want := []string{"/c", "/b", "/d"}; !reflect.DeepEqual(cfg.PluginDirs, want) {
+		t.Errorf("PluginDirs = %v, want %v", cfg.PluginDirs, want)
+	}
+	if got := cfg.Plugins["docker"].Install["work-dir"]; got != "/opt/docker" {
+		t.Errorf("docker work-dir = %v", got)
+	}
+	if got := cfg.Plugins["nginx"].Install["port"]; got != 8080 {
+		t.Errorf("nginx port = %#v", got)
+	}
+	if cfg.Executor == nil || cfg.Executor.Shell != "/bin/zsh" {
+		t.Errorf("Executor = %+v", cfg.Executor)
+	}
+	if cfg.InlinePlugins[0].Desc != "Say hi" {
+		t.Errorf("InlinePlugins[0] = %+v", cfg.InlinePlugins[0])
+	}
+
+	// 失败时不修改配置
+	before := testConfig()
+	for _, tt := range []struct {
+		path  string
+		value any
+		err   string
+	}{
+		{"unknown_key", "x", "unknown field"},
+		{"plugins.docker.other", "x", "unknown field"},
+		{"version", "one", "expected integer"},
+		{"plugin_dirs", "/a", "expected array"},
+		{"plugin_dirs[5]", "/x", "out of range"},
+		{"version[0]", 1, "not a list"},
+		{"plugin_dirs.first", "/x", "not a mapping"},
+	} {
+		cfg := testConfig()
+		err := SetConfigValue(cfg, tt.path, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("SetConfigValue(%q, %v) error = %v, want %q", tt.path, tt.value, err, tt.err)
+		}
+		if !reflect.DeepEqual(cfg, before) {
+			t.Errorf("SetConfigValue(%q) modified config on error: %+v", tt.path, cfg)
+		}
+	}
+}
+
+func TestUnsetConfigValue(t *testing.T) {
+	cfg := testConfig()
+	for _, path := range []string{"plugin_dirs[0]", "plugins.docker.install.work-dir", "inline_plugins[0]"} {
+		if err := UnsetConfigValue(cfg, path); err != nil {
+			t.Fatalf("UnsetConfigValue(%q): %v", path, err)
+		}
+	}
+	if want := []string{"/b"}; !reflect.DeepEqual(cfg.PluginDirs, want) {
+		t.Errorf("PluginDirs = %v, want %v", cfg.PluginDirs, want)
+	}
+	if _, ok := cfg.Plugins["docker"].Install["work-dir"]; ok {
+		t.Errorf("docker work-dir still set: %+v", cfg.Plugins["docker"])
+	}
+	if len(cfg.InlinePlugins) != 0 {
+		t.Errorf("InlinePlugins = %+v, want empty", cfg.InlinePlugins)
+	}
+
+	for _, path := range []string{"missing", "plugin_dirs[3]", "plugins.nginx.install", "version.major"} {
+		if err := UnsetConfigValue(cfg, path); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("UnsetConfigValue(%q) error = %v, want not found", path, err)
+		}
+	}
+	// 删除必填字段后 Schema 校验失败
+	cfg = testConfig()
+	if err := UnsetConfigValue(cfg, "inline_plugins[0].run"); err == nil {
+		t.Error("UnsetConfigValue(inline_plugins[0].run) expected schema error")
+	}
+}
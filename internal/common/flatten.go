@@ -0,0 +1,75 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ConfigEntry 展开后的单个配置项
+type ConfigEntry struct {
+	Path  string // 例如 plugins.docker.install.work-dir、plugin_dirs[0]
+	Value string
+}
+
+// FlattenConfig 按字段顺序把配置展开为叶子节点列表，空的列表/映射以 []/{} 表示
+func FlattenConfig(cfg *Config) ([]ConfigEntry, error) {
+	var node yaml.Node
+	if err := node.Encode(cfg); err != nil {
+		return nil, err
+	}
+	var entries []ConfigEntry
+	flattenNode(&node, "", &entries)
+	return entries, nil
+}
+
+func flattenNode(node *yaml.Node, path string, entries *[]ConfigEntry) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, c := range node.Content {
+			flattenNode(c, path, entries)
+		}
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			*entries = append(*entries, ConfigEntry{Path: path, Value: "{}"})
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenNode(node.Content[i+1], key, entries)
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			*entries = append(*entries, ConfigEntry{Path: path, Value: "[]"})
+			return
+		}
+		for i, c := range node.Content {
+			flattenNode(c, fmt.Sprintf("%s[%d]", path, i), entries)
+		}
+	default:
+		value := node.Value
+		if node.ShortTag() == "!!null" {
+			value = "null"
+		}
+		*entries = append(*entries, ConfigEntry{Path: path, Value: value})
+	}
+}
+
+// LookupOrigin 查找配置项的来源，找不到时回退到最近的上级路径
+func (lc *LayeredConfig) LookupOrigin(path string) string {
+	for p := path; p != ""; {
+		if origin, ok := lc.Origins[p]; ok {
+			return origin
+		}
+		i := strings.LastIndexAny(p, ".[")
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	return ""
+}
@@ -0,0 +1,321 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// SystemConfigPath 组织级默认配置
+	SystemConfigPath = "/etc/tool/tool.yml"
+	// ProjectConfigName 项目级配置文件名，从当前目录向上查找
+	ProjectConfigName = ".tool.yml"
+	// OriginDefault 来自内置默认值的配置项
+	OriginDefault = "default"
+)
+
+// LayerOptions 分层加载配置的输入，全部可注入以便测试
+type LayerOptions struct {
+	SystemPath string  // 系统级配置，为空则跳过
+	UserPath   string  // 用户级配置（或 --config 指定的文件），也是写入目标
+	WorkDir    string  // 查找项目级 .tool.yml 的起始目录，为空则跳过
+	Environ    Environ // TOOL_* 覆盖、~ 展开和默认插件目录使用的环境变量
+}
+
+// ConfigLayer 单个配置层的加载结果
+type ConfigLayer struct {
+	Name string // system / user / project
+	Path string
+	Err  error // 文件存在但解析失败
+}
+
+// LayeredConfig 合并后的配置及每个配置项的来源
+type LayeredConfig struct {
+	Cfg     *Config
+	Origins map[string]string // 配置项路径（如 plugins.docker.install.work-dir）-> 来源
+	Layers  []ConfigLayer     // 实际存在的配置层，按优先级从低到高
+	Profile string            // 已应用的 profile，为空表示未使用
+
+	listOrigins map[string]map[string]string // 列表字段 -> 元素值 -> 来源
+	home        string                       // 展开 ~ 使用的主目录
+}
+
+// NewLayeredConfig 包装直接注入的配置（不读取配置文件和环境变量），未设置的执行器使用默认值
+func NewLayeredConfig(cfg *Config) *LayeredConfig {
+	if cfg.Plugins == nil {
+		cfg.Plugins = map[string]PluginConfig{}
+	}
+	lc := &LayeredConfig{
+		Cfg:         cfg,
+		Origins:     map[string]string{},
+		listOrigins: map[string]map[string]string{},
+	}
+	lc.fillDefaults()
+	return lc
+}
+
+// DefaultUserConfigPath 返回默认的用户级配置路径 ~/.config/tool.yml
+func DefaultUserConfigPath(env Environ) string {
+	return filepath.Join(env.HomeDir(), ".config", "tool.yml")
+}
+
+// DefaultPluginCachePath 返回插件元数据缓存路径，默认 ~/.cache/tool/plugins.yml，
+// 缓存目录的选择与 os.UserCacheDir 一致（Linux 遵循 XDG_CACHE_HOME）
+func DefaultPluginCachePath(env Environ) string {
+	var dir string
+	switch runtime.GOOS {
+	case "windows":
+		dir = env.Get("LocalAppData")
+	case "darwin", "ios":
+		dir = filepath.Join(env.HomeDir(), "Library", "Caches")
+	default:
+		if dir = env.Get("XDG_CACHE_HOME"); !filepath.IsAbs(dir) {
+			dir = filepath.Join(env.HomeDir(), ".cache")
+		}
+	}
+	return filepath.Join(dir, "tool", "plugins.yml")
+}
+
+// FindProjectConfig 从 dir 开始逐级向上查找 .tool.yml，找不到返回空字符串
+func FindProjectConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectConfigName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadLayeredConfig 依次加载系统、用户、项目配置和 TOOL_* 环境变量并合并
+// 合并规则：
+//   - plugin_dirs / enabled_plugins：按层顺序追加并去重
+//   - plugins：按插件、install/uninstall、flag 逐级深度合并，高优先级覆盖
+//   - executor：非空字段由高优先级覆盖
+//   - inline_plugins：按名称合并，高优先级整体替换
+//
+// 所有配置文件都不存在时以 GenerateDefault 的默认配置为基础，TOOL_* 环境变量叠加在其上
+func LoadLayeredConfig(opts LayerOptions) *LayeredConfig {
+	lc := &LayeredConfig{
+		Cfg:         &Config{Version: CurrentConfigVersion, Plugins: map[string]PluginConfig{}},
+		Origins:     map[string]string{},
+		listOrigins: map[string]map[string]string{},
+		home:        opts.Environ.HomeDir(),
+	}
+
+	files := []ConfigLayer{{Name: "system", Path: opts.SystemPath}, {Name: "user", Path: opts.UserPath}}
+	if opts.WorkDir != "" {
+		files = append(files, ConfigLayer{Name: "project", Path: FindProjectConfig(opts.WorkDir)})
+	}
+	for _, layer := range files {
+		if layer.Path == "" {
+			continue
+		}
+		if _, err := os.Stat(layer.Path); err != nil {
+			continue
+		}
+		cfg, err := LoadConfig(layer.Path)
+		layer.Err = err
+		lc.Layers = append(lc.Layers, layer)
+		if err == nil {
+			lc.merge(cfg, layer.Path)
+		}
+	}
+	if len(lc.Layers) == 0 {
+		lc.Cfg = GenerateDefault(opts.Environ)
+		lc.mergeDefaults(lc.Cfg)
+	}
+	lc.mergeEnv(opts.Environ, opts.WorkDir)
+	lc.fillDefaults()
+	lc.finalizeListOrigins()
+	return lc
+}
+
+// Found 是否存在至少一个配置文件，解析失败的配置文件也算存在
+func (lc *LayeredConfig) Found() bool {
+	return len(lc.Layers) > 0
+}
+
+func (lc *LayeredConfig) appendList(field string, dst *[]string, items []string, origin string) {
+	if lc.listOrigins[field] == nil {
+		lc.listOrigins[field] = map[string]string{}
+	}
+	for _, item := range items {
+		if _, ok := lc.listOrigins[field][item]; ok {
+			continue
+		}
+		*dst = append(*dst, item)
+		lc.listOrigins[field][item] = origin
+	}
+}
+
+func (lc *LayeredConfig) mergeFlags(dst map[string]any, src map[string]any, prefix, origin string) map[string]any {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]any{}
+	}
+	for k, v := range src {
+		dst[k] = v
+		lc.Origins[prefix+"."+k] = origin
+	}
+	return dst
+}
+
+func (lc *LayeredConfig) merge(src *Config, origin string) {
+	cfg := lc.Cfg
+	if cfg.Profiles == nil && len(src.Profiles) > 0 {
+		cfg.Profiles = map[string]Profile{}
+	}
+	// 相对路径基于声明它的配置文件所在目录，与执行 tool 时的当前目录无关
+	lc.appendList("plugin_dirs", &cfg.PluginDirs, lc.resolvePaths(src.PluginDirs, filepath.Dir(origin)), origin)
+	if src.PluginDepth != 0 {
+		cfg.PluginDepth = src.PluginDepth
+		lc.Origins["plugin_depth"] = origin
+	}
+	lc.appendList("enabled_plugins", &cfg.EnabledPlugins, src.EnabledPlugins, origin)
+	lc.appendList("allow_override", &cfg.AllowOverride, src.AllowOverride, origin)
+
+	for name, pc := range src.Plugins {
+		cur := cfg.Plugins[name]
+		prefix := "plugins." + name
+		cur.Install = lc.mergeFlags(cur.Install, pc.Install, prefix+".install", origin)
+		cur.Uninstall = lc.mergeFlags(cur.Uninstall, pc.Uninstall, prefix+".uninstall", origin)
+		if pc.Defaults != nil {
+			cur.Defaults = pc.Defaults
+			lc.Origins[prefix+".defaults"] = origin
+		}
+		cfg.Plugins[name] = cur
+	}
+
+	if src.Executor != nil {
+		lc.mergeExecutor(src.Executor.Shell, src.Executor.Python, origin, origin)
+	}
+
+	for _, p := range src.InlinePlugins {
+		lc.mergeInlinePlugin(p, origin)
+	}
+
+	for name, p := range src.Profiles {
+		lc.mergeProfile(name, p, origin)
+	}
+}
+
+// mergeInlinePlugin 合并内联插件，同名插件由高优先级的配置层整体替换
+func (lc *LayeredConfig) mergeInlinePlugin(p InlinePlugin, origin string) {
+	p.Dir = filepath.Dir(origin)
+	for i, cur := range lc.Cfg.InlinePlugins {
+		if cur.Name == p.Name {
+			lc.Cfg.InlinePlugins[i] = p
+			lc.Origins[fmt.Sprintf("inline_plugins[%d]", i)] = origin
+			return
+		}
+	}
+	lc.Cfg.InlinePlugins = append(lc.Cfg.InlinePlugins, p)
+	lc.Origins[fmt.Sprintf("inline_plugins[%d]", len(lc.Cfg.InlinePlugins)-1)] = origin
+}
+
+// resolvePaths 展开 ~ 并把相对路径转换为基于 base 的绝对路径
+func (lc *LayeredConfig) resolvePaths(paths []string, base string) []string {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		out = append(out, ResolvePath(p, base, lc.home))
+	}
+	return out
+}
+
+func (lc *LayeredConfig) mergeExecutor(shell, python, shellOrigin, pythonOrigin string) {
+	if lc.Cfg.Executor == nil {
+		lc.Cfg.Executor = &Executor{}
+	}
+	if shell != "" {
+		lc.Cfg.Executor.Shell = shell
+		lc.Origins["executor.shell"] = shellOrigin
+	}
+	if python != "" {
+		lc.Cfg.Executor.Python = python
+		lc.Origins["executor.python"] = pythonOrigin
+	}
+}
+
+// mergeEnv 应用 TOOL_* 环境变量覆盖，只支持以下四个变量，
+// 其他配置项（plugins、inline_plugins、profiles 等）只能在配置文件中设置，其余 TOOL_* 变量被忽略：
+//   - TOOL_PLUGIN_DIRS：以系统路径分隔符分隔，追加到 plugin_dirs，相对路径基于当前目录
+//   - TOOL_ENABLED_PLUGINS：以逗号分隔，追加到 enabled_plugins
+//   - TOOL_EXECUTOR_SHELL / TOOL_EXECUTOR_PYTHON：覆盖执行器
+func (lc *LayeredConfig) mergeEnv(environ Environ, workDir string) {
+	env := map[string]string{}
+	for _, kv := range environ.List() {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, "TOOL_") && v != "" {
+			env[k] = v
+		}
+	}
+	if v, ok := env["TOOL_PLUGIN_DIRS"]; ok {
+		lc.appendList("plugin_dirs", &lc.Cfg.PluginDirs, lc.resolvePaths(filepath.SplitList(v), workDir), "env:TOOL_PLUGIN_DIRS")
+	}
+	if v, ok := env["TOOL_ENABLED_PLUGINS"]; ok {
+		var names []string
+		for _, n := range strings.Split(v, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				names = append(names, n)
+			}
+		}
+		lc.appendList("enabled_plugins", &lc.Cfg.EnabledPlugins, names, "env:TOOL_ENABLED_PLUGINS")
+	}
+	shell, python := env["TOOL_EXECUTOR_SHELL"], env["TOOL_EXECUTOR_PYTHON"]
+	if shell != "" || python != "" {
+		lc.mergeExecutor(shell, python, "env:TOOL_EXECUTOR_SHELL", "env:TOOL_EXECUTOR_PYTHON")
+	}
+}
+
+// fillDefaults 为配置层未设置的执行器补充默认值
+func (lc *LayeredConfig) fillDefaults() {
+	def := defaultExecutor()
+	cfg := lc.Cfg
+	if cfg.Executor == nil {
+		cfg.Executor = &Executor{}
+	}
+	if cfg.Executor.Shell == "" {
+		cfg.Executor.Shell = def.Shell
+		lc.Origins["executor.shell"] = OriginDefault
+	}
+	if cfg.Executor.Python == "" {
+		cfg.Executor.Python = def.Python
+		lc.Origins["executor.python"] = OriginDefault
+	}
+}
+
+// mergeDefaults 记录默认配置中每一项的来源
+func (lc *LayeredConfig) mergeDefaults(def *Config) {
+	lc.appendList("plugin_dirs", new([]string), def.PluginDirs, OriginDefault)
+	lc.Origins["executor.shell"] = OriginDefault
+	lc.Origins["executor.python"] = OriginDefault
+}
+
+// finalizeListOrigins 将列表元素的来源转换为带下标的路径，例如 plugin_dirs[0]
+func (lc *LayeredConfig) finalizeListOrigins() {
+	lists := map[string][]string{
+		"plugin_dirs":     lc.Cfg.PluginDirs,
+		"enabled_plugins": lc.Cfg.EnabledPlugins,
+		"allow_override":  lc.Cfg.AllowOverride,
+	}
+	for field, items := range lists {
+		for i, item := range items {
+			if origin, ok := lc.listOrigins[field][item]; ok {
+				lc.Origins[fmt.Sprintf("%s[%d]", field, i)] = origin
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadLayeredConfigEnvWithoutConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	env := Environ{"HOME=" + dir, "XDG_DATA_HOME=", "TOOL_PLUGIN_DIRS=extra", "TOOL_EXECUTOR_SHELL=/bin/zsh", "TOOL_UNKNOWN=1"}
+	lc := LoadLayeredConfig(LayerOptions{UserPath: filepath.Join(dir, "missing.yml"), WorkDir: dir, Environ: env})
+
+	if lc.Found() {
+		t.Errorf("Layers = %+v, want none", lc.Layers)
+	}
+	defaultDir := DefaultPluginDir(env)
+	want := []string{defaultDir, filepath.Join(dir, "extra")}
+	if !reflect.DeepEqual(lc.Cfg.PluginDirs, want) {
+		t.Errorf("PluginDirs = %q, want %q", lc.Cfg.PluginDirs, want)
+	}
+	if lc.Cfg.Executor.Shell != "/bin/zsh" || lc.Cfg.Executor.Python != "/usr/bin/python3" {
+		t.Errorf("Executor = %+v, want shell from env and default python", lc.Cfg.Executor)
+	}
+	origins := map[string]string{
+		"plugin_dirs[0]":  OriginDefault,
+		"plugin_dirs[1]":  "env:TOOL_PLUGIN_DIRS",
+		"executor.shell":  "env:TOOL_EXECUTOR_SHELL",
+		"executor.python": OriginDefault,
+	}
+	for path, want := range origins {
+		if got := lc.LookupOrigin(path); got != want {
+			t.Errorf("origin of %s = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestLoadLayeredConfigDefaultsWithoutConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	env := Environ{"HOME=" + dir, "XDG_DATA_HOME=" + filepath.Join(dir, "data")}
+	lc := LoadLayeredConfig(LayerOptions{UserPath: filepath.Join(dir, "missing.yml"), Environ: env})
+
+	want := []string{filepath.Join(dir, "data", "tool", "plugins")}
+	if !reflect.DeepEqual(lc.Cfg.PluginDirs, want) {
+		t.Errorf("PluginDirs = %q, want %q", lc.Cfg.PluginDirs, want)
+	}
+	if got := lc.LookupOrigin("plugin_dirs[0]"); got != OriginDefault {
+		t.Errorf("origin of plugin_dirs[0] = %q, want %q", got, OriginDefault)
+	}
+}
+
+func TestLoadLayeredConfigResolvesPathsRelativeToConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	systemPath := filepath.Join(dir, "etc", "tool.yml")
+	userPath := filepath.Join(home, ".config", "tool.yml")
+	project := filepath.Join(dir, "work", "proj")
+	writeConfig(t, systemPath, "plugin_dirs: [sys]\n")
+	writeConfig(t, userPath, `plugin_dirs:
+  - plugins
+  - ~/shared
+  - ../up
+  - /abs/plugins
+inline_plugins:
+  - name: hi
+    run: echo hi
+`)
+	writeConfig(t, filepath.Join(project, ProjectConfigName), "plugin_dirs: [local, ./plugins]\n")
+
+	env := Environ{"HOME=" + home, "TOOL_PLUGIN_DIRS=from-env"}
+	lc := LoadLayeredConfig(LayerOptions{
+		SystemPath: systemPath,
+		UserPath:   userPath,
+		WorkDir:    filepath.Join(project, "sub"),
+		Environ:    env,
+	})
+	for _, layer := range lc.Layers {
+		if layer.Err != nil {
+			t.Fatalf("layer %s: %v", layer.Name, layer.Err)
+		}
+	}
+
+	// 相对路径基于声明它的配置文件所在目录，TOOL_PLUGIN_DIRS 基于当前目录
+	want := []string{
+		filepath.Join(dir, "etc", "sys"),
+		filepath.Join(home, ".config", "plugins"),
+		filepath.Join(home, "shared"),
+		filepath.Join(home, "up"),
+		"/abs/plugins",
+		filepath.Join(project, "local"),
+		filepath.Join(project, "plugins"),
+		filepath.Join(project, "sub", "from-env"),
+	}
+	if !reflect.DeepEqual(lc.Cfg.PluginDirs, want) {
+		t.Errorf("PluginDirs =\n  %q\nwant\n  %q", lc.Cfg.PluginDirs, want)
+	}
+	origins := []string{systemPath, userPath, userPath, userPath, userPath,
+		filepath.Join(project, ProjectConfigName), filepath.Join(project, ProjectConfigName), "env:TOOL_PLUGIN_DIRS"}
+	for i, want := range origins {
+		path := fmt.Sprintf("plugin_dirs[%d]", i)
+		if got := lc.LookupOrigin(path); got != want {
+			t.Errorf("origin of %s = %q, want %q", path, got, want)
+		}
+	}
+
+	// inline 插件在声明它的配置文件所在目录中运行
+	if len(lc.Cfg.InlinePlugins) != 1 || lc.Cfg.InlinePlugins[0].Dir != filepath.Dir(userPath) {
+		t.Errorf("InlinePlugins = %+v, want Dir %s", lc.Cfg.InlinePlugins, filepath.Dir(userPath))
+	}
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// MetaFileNames 插件清单的文件名，同一目录中存在多个时按此顺序取第一个
+var MetaFileNames = []string{"meta.yml", "meta.yaml", "meta.json", "meta.toml"}
+
+// FindMetaFile 返回 dir 中的插件清单路径，不存在时返回空字符串
+func FindMetaFile(dir string) string {
+	for _, name := range MetaFileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// IsTOMLMeta 判断清单是否为 TOML 格式
+func IsTOMLMeta(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// ReadMetaFile 读取插件清单并返回 YAML 文档，之后统一按 YAML 校验和解析：
+// JSON 是 YAML 的子集，原样返回；TOML 解码后重新编码为 YAML，行号与原文件不再对应
+func ReadMetaFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	if !IsTOMLMeta(path) {
+		return data, nil
+	}
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return yaml.Marshal(doc)
+}
@@ -2,6 +2,9 @@ package common
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/bookandmusic/tool/internal/schema"
 )
 
 // 定义 PluginType 类型
@@ -24,6 +27,16 @@ func (p PluginType) String() string {
 	}
 }
 
+// JSONSchema PluginType 在 YAML 中以字符串表示
+func (p PluginType) JSONSchema() *schema.Schema {
+	return &schema.Schema{Type: "string", Enum: []any{Soft.String(), Command.String()}}
+}
+
+// MarshalYAML PluginType 在 YAML 中以字符串表示
+func (p PluginType) MarshalYAML() (interface{}, error) {
+	return p.String(), nil
+}
+
 // 为 PluginType 类型实现 UnmarshalYAML 方法
 func (p *PluginType) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var s string
@@ -31,41 +44,112 @@ func (p *PluginType) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
-	// 根据字符串值设置 PluginType
+	t, err := ParsePluginType(s)
+	if err != nil {
+		return err
+	}
+	*p = t
+	return nil
+}
+
+// ParsePluginType 根据字符串解析 PluginType
+func ParsePluginType(s string) (PluginType, error) {
 	switch s {
 	case "soft":
-		*p = Soft
+		return Soft, nil
 	case "command":
-		*p = Command
+		return Command, nil
 	default:
-		return fmt.Errorf("invalid plugin type: %s", s)
+		return Soft, fmt.Errorf("invalid plugin type: %s", s)
 	}
-	return nil
 }
 
+// 支持显式声明的 flag 类型，未声明时根据 default 推断
+const (
+	FlagString = "string"
+	FlagBool   = "bool"
+	FlagInt    = "int"
+	FlagFloat  = "float"
+)
+
 type CommandFlag struct {
-	Name    string `yaml:"name"`
-	Desc    string `yaml:"desc"`
-	Default any    `yaml:"default"`
+	Name    string `yaml:"name" schema:"required" desc:"Flag name, passed to the plugin as --name=value"`
+	Desc    string `yaml:"desc,omitempty" desc:"Help text of the flag"`
+	Type    string `yaml:"type,omitempty" enum:"string|bool|int|float" desc:"Flag type, inferred from default when omitted"` // 可选
+	Default any    `yaml:"default,omitempty" desc:"Default value of the flag"`
 }
 
 type CommandDef struct {
-	Name  string         `yaml:"name"`
-	Flags []*CommandFlag `yaml:"flags"`
-	Desc  string         `yaml:"desc"`
+	Name  string         `yaml:"name" schema:"required" desc:"Subcommand name"`
+	Flags []*CommandFlag `yaml:"flags" desc:"Flags of the subcommand"`
+	Desc  string         `yaml:"desc" desc:"Help text of the subcommand"`
+}
+
+// Platform 插件支持的平台，字段为空表示不限制
+type Platform struct {
+	OS     []string `yaml:"os" desc:"Operating systems, e.g. linux, darwin"`                  // 操作系统
+	Arch   []string `yaml:"arch" desc:"Architectures, e.g. amd64, arm64"`                     // 架构
+	Distro []string `yaml:"distro" desc:"Distribution IDs from /etc/os-release (ID/ID_LIKE)"` // 发行版 ID
+}
+
+// Requires 插件运行的前置条件
+type Requires struct {
+	Binaries []string `yaml:"binaries" desc:"Executables that must be found in PATH"` // PATH 中必须存在的可执行文件
+	Kernel   string   `yaml:"kernel" desc:"Minimum kernel version, e.g. 5.4"`         // 最低内核版本
+	Root     bool     `yaml:"root" desc:"Whether the plugin must run as root"`        // 是否需要 root 权限
 }
 
 type Meta struct {
-	Name     string         `yaml:"name"`
-	Desc     string         `yaml:"desc"`
-	Type     PluginType     `yaml:"type"`
-	Exec     string         `yaml:"exec"`
-	ExecType string         `yaml:"exec_type"`
-	Flags    []*CommandFlag `yaml:"flags"`    // 通用 flags
-	Commands []CommandDef   `yaml:"commands"` // 子命令
-	Dir      string         `yaml:"-"`        // 插件目录
-	BuiltIn  bool           `yaml:"-"`        // 内置插件
-	Service  Service        `yaml:"-"`        // 插件绑定的服务实例
+	Name         string         `yaml:"name" schema:"required" desc:"Plugin name, used as the command name"`
+	Namespace    string         `yaml:"namespace" desc:"Namespace of the plugin, also reachable as namespace/name"` // 命名空间，重名时仍可通过 namespace/name 调用
+	Desc         string         `yaml:"desc" desc:"Short description shown in help"`
+	Type         PluginType     `yaml:"type" schema:"required" desc:"Plugin type"`
+	Version      string         `yaml:"version" desc:"Plugin version"`                                     // 插件版本
+	RequiresTool string         `yaml:"requires_tool" desc:"Required tool version constraint, e.g. >=0.3"` // 依赖的工具版本约束
+	Exec         string         `yaml:"exec" schema:"required" desc:"Script to execute, relative to the plugin directory"`
+	ExecType     string         `yaml:"exec_type" enum:"shell|python" desc:"Executor used to run exec, runs it directly when omitted"`
+	Platforms    []Platform     `yaml:"platforms" desc:"Supported platforms, any entry may match"`          // 支持的平台，满足任意一项即可
+	Requires     *Requires      `yaml:"requires" desc:"Prerequisites checked before the plugin is offered"` // 前置条件
+	Flags        []*CommandFlag `yaml:"flags" desc:"Flags of the top-level command"`                        // 通用 flags
+	Commands     []CommandDef   `yaml:"commands" desc:"Subcommands"`                                        // 子命令
+	Dir          string         `yaml:"-"`                                                                  // 插件目录
+	BuiltIn      bool           `yaml:"-"`                                                                  // 内置插件
+	Unsupported  string         `yaml:"-"`                                                                  // 当前主机不支持的原因，为空表示支持
+	Service      Service        `yaml:"-"`                                                                  // 插件绑定的服务实例
+
+	loader func() (*Meta, error) // 延迟加载时读取完整清单，为空表示已完整加载
+}
+
+// SetLoader 把插件标记为延迟加载：只有名称、描述等摘要可用，flags 和子命令在 Load 时由 load 读取清单补全
+func (m *Meta) SetLoader(load func() (*Meta, error)) {
+	m.loader = load
+}
+
+// Lazy 插件是否尚未完整加载
+func (m *Meta) Lazy() bool {
+	return m.loader != nil
+}
+
+// Load 补全延迟加载插件的 flags 和子命令，已完整加载时直接返回
+func (m *Meta) Load() error {
+	if m.loader == nil {
+		return nil
+	}
+	full, err := m.loader()
+	if err != nil {
+		return err
+	}
+	m.Flags, m.Commands = full.Flags, full.Commands
+	m.loader = nil
+	return nil
+}
+
+// QualifiedName 返回带命名空间的插件名称，例如 team/docker，未声明命名空间时返回 Name
+func (m *Meta) QualifiedName() string {
+	if m.Namespace == "" || strings.HasPrefix(m.Name, m.Namespace+"/") {
+		return m.Name
+	}
+	return m.Namespace + "/" + m.Name
 }
 
 func (m *Meta) GetCommand(name string) *CommandDef {
@@ -0,0 +1,189 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion 当前配置结构版本，修改 Config 结构且不兼容旧文件时递增并注册迁移
+const CurrentConfigVersion = 1
+
+// Migration 把 From 版本的配置升级到 From+1，直接修改 YAML 根映射节点以保留注释
+type Migration struct {
+	From  int
+	Desc  string
+	Apply func(root *yaml.Node) error
+}
+
+var migrations = map[int]Migration{}
+
+// RegisterMigration 注册配置迁移，每个起始版本只能注册一个
+func RegisterMigration(m Migration) {
+	if _, ok := migrations[m.From]; ok {
+		panic(fmt.Sprintf("config migration from version %d already registered", m.From))
+	}
+	migrations[m.From] = m
+}
+
+func init() {
+	// v0：没有 version 字段的旧配置，结构与 v1 相同，仅补充版本号
+	RegisterMigration(Migration{
+		From:  0,
+		Desc:  "add version field",
+		Apply: func(root *yaml.Node) error { return nil },
+	})
+}
+
+// MigrationResult 迁移结果
+type MigrationResult struct {
+	Path     string
+	From     int
+	To       int
+	Steps    []string // 依次执行的迁移说明
+	Before   []byte
+	After    []byte
+	Backup   string // 备份文件路径，dry-run 或无需迁移时为空
+	Migrated bool
+}
+
+// configVersion 读取根映射中的 version，缺失视为 0
+func configVersion(root *yaml.Node) (int, error) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			v, err := strconv.Atoi(root.Content[i+1].Value)
+			if err != nil {
+				return 0, fmt.Errorf("invalid config version %q", root.Content[i+1].Value)
+			}
+			return v, nil
+		}
+	}
+	return 0, nil
+}
+
+// setConfigVersion 写入 version，不存在时插入到最前面
+func setConfigVersion(root *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			root.Content[i+1].Value = value
+			root.Content[i+1].Tag = "!!int"
+			return
+		}
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+	// 文件开头的注释保留在最前面
+	if len(root.Content) > 0 {
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, val}, root.Content...)
+}
+
+// migrateNode 在文档节点上依次执行迁移，返回起始版本和执行的步骤
+func migrateNode(doc *yaml.Node) (int, []string, error) {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return CurrentConfigVersion, nil, nil
+	}
+	root := doc.Content[0]
+	from, err := configVersion(root)
+	if err != nil {
+		return 0, nil, err
+	}
+	if from > CurrentConfigVersion {
+		return from, nil, fmt.Errorf("config version %d is newer than supported version %d, please upgrade tool", from, CurrentConfigVersion)
+	}
+	var steps []string
+	for v := from; v < CurrentConfigVersion; v++ {
+		m, ok := migrations[v]
+		if !ok {
+			return from, steps, fmt.Errorf("no migration registered from config version %d", v)
+		}
+		if err := m.Apply(root); err != nil {
+			return from, steps, fmt.Errorf("migrate config v%d -> v%d: %w", v, v+1, err)
+		}
+		setConfigVersion(root, v+1)
+		steps = append(steps, fmt.Sprintf("v%d -> v%d: %s", v, v+1, m.Desc))
+	}
+	return from, steps, nil
+}
+
+// MigrateConfigData 在内存中把配置文本升级到当前版本，已是最新时原样返回
+func MigrateConfigData(data []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	_, steps, err := migrateNode(&doc)
+	if err != nil || len(steps) == 0 {
+		return data, steps, err
+	}
+	out, err := encodeNode(&doc, detectIndent(data))
+	return out, steps, err
+}
+
+func encodeNode(doc *yaml.Node, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MigrateConfigFile 把配置文件升级到当前版本
+// 非 dry-run 时先把原文件备份为 <path>.v<旧版本>.bak，再原子写入新内容
+func MigrateConfigFile(path string, dryRun bool) (*MigrationResult, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	from, steps, err := migrateNode(&doc)
+	res := &MigrationResult{Path: path, From: from, To: CurrentConfigVersion, Steps: steps, Before: data, After: data}
+	if err != nil || len(steps) == 0 {
+		return res, err
+	}
+	if res.After, err = encodeNode(&doc, detectIndent(data)); err != nil {
+		return res, err
+	}
+	if err := ConfigSchema().ValidateYAML(res.After); err != nil {
+		return res, fmt.Errorf("migrated config is invalid: %w", err)
+	}
+	if dryRun {
+		return res, nil
+	}
+
+	if res.Backup, err = backupConfig(path, data, from); err != nil {
+		return res, err
+	}
+	if err := WriteFileAtomic(path, res.After, 0o600); err != nil {
+		return res, err
+	}
+	res.Migrated = true
+	return res, nil
+}
+
+// backupConfig 把 from 版本的配置原文备份为 <path>.v<from>.bak，已存在时追加时间戳，返回备份路径
+func backupConfig(path string, data []byte, from int) (string, error) {
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	if _, err := os.Stat(backup); err == nil {
+		backup = fmt.Sprintf("%s.v%d.%s.bak", path, from, time.Now().Format("20060102150405"))
+	}
+	if err := WriteFileAtomic(backup, data, 0o600); err != nil {
+		return "", fmt.Errorf("backup config: %w", err)
+	}
+	return backup, nil
+}
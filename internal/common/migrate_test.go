@@ -0,0 +1,130 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const v0Config = `# my config
+plugin_dirs:
+  - ./plugins
+enabled_plugins: []
+`
+
+func writeV0Config(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tool.yml")
+	if err := os.WriteFile(path, []byte(v0Config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigMigratesInMemoryOnly(t *testing.T) {
+	path := writeV0Config(t)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != CurrentConfigVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, CurrentConfigVersion)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != v0Config {
+		t.Errorf("LoadConfig modified the file:\n%s", data)
+	}
+	if matches, _ := filepath.Glob(path + ".*.bak"); len(matches) != 0 {
+		t.Errorf("LoadConfig created backups: %v", matches)
+	}
+}
+
+func TestMigrateConfigFileDryRun(t *testing.T) {
+	path := writeV0Config(t)
+	res, err := MigrateConfigFile(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.From != 0 || res.Migrated || res.Backup != "" || len(res.Steps) != 1 {
+		t.Errorf("dry run result = %+v", res)
+	}
+	if !strings.HasPrefix(string(res.After), "# my config\nversion: 1\n") {
+		t.Errorf("After = %q, want version inserted below the header comment", res.After)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != v0Config {
+		t.Error("dry run modified the file")
+	}
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	path := writeV0Config(t)
+	res, err := MigrateConfigFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Migrated || res.Backup != path+".v0.bak" {
+		t.Fatalf("result = %+v", res)
+	}
+	backup, _ := os.ReadFile(res.Backup)
+	if string(backup) != v0Config {
+		t.Errorf("backup = %q", backup)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != string(res.After) {
+		t.Errorf("file = %q, want %q", data, res.After)
+	}
+}
+
+func TestSaveConfigBacksUpOldVersion(t *testing.T) {
+	path := writeV0Config(t)
+	err := UpdateConfig(path, nil, func(cfg *Config) error {
+		cfg.EnabledPlugins = append(cfg.EnabledPlugins, "docker")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil {
+		t.Fatalf("backup not created: %v", err)
+	}
+	if string(backup) != v0Config {
+		t.Errorf("backup = %q", backup)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != CurrentConfigVersion || len(cfg.EnabledPlugins) != 1 {
+		t.Errorf("saved config = %+v", cfg)
+	}
+
+	// 已是当前版本，再次保存不再备份
+	if err := UpdateConfig(path, nil, func(cfg *Config) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if matches, _ := filepath.Glob(path + ".v*.bak"); len(matches) != 1 {
+		t.Errorf("backups = %v, want only the v0 backup", matches)
+	}
+}
+
+func TestSaveConfigMigratesV0File(t *testing.T) {
+	path := writeV0Config(t)
+	cfg := &Config{Version: CurrentConfigVersion, PluginDirs: []string{"./plugins"}, EnabledPlugins: []string{"docker"}}
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# my config\nversion: 1\nplugin_dirs:\n  - ./plugins\nenabled_plugins:\n  - docker\n"
+	if !strings.HasPrefix(string(data), want) {
+		t.Errorf("saved file = %q, want prefix %q", data, want)
+	}
+	if _, err := os.Stat(path + ".v0.bak"); err != nil {
+		t.Errorf("backup not created: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package common
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ExtensionPrefix 配置中以该前缀开头的键为用户扩展字段，加载时忽略，写入时保留
+const ExtensionPrefix = "x-"
+
+// WriteFileAtomic 原子写入文件：写入同目录临时文件并 fsync 后 rename 覆盖，
+// 中途崩溃不会留下被截断的目标文件。path 是符号链接时写入链接指向的文件，
+// 目标文件已存在时保留其权限，perm 只用于新建的文件
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	cleanup := func() { _ = os.Remove(tmpPath) }
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		cleanup()
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		cleanup()
+		return err
+	}
+	// 同步目录项，确保 rename 落盘
+	if d, err := os.Open(filepath.Clean(dir)); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
+
+// SaveConfig 保存配置
+// 目标文件已存在时在其 yaml.Node 树上原地修改，保留注释、键顺序和未知键，然后原子写入；
+// 目标文件是旧版本配置时，写入前先按 config migrate 的规则备份原文件并迁移节点树，
+// 缺失的 version 插入到最前面
+func SaveConfig(path string, cfg *Config) error {
+	var updated yaml.Node
+	if err := updated.Encode(cfg); err != nil {
+		return err
+	}
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&updated}}
+	indent := 2
+	if data, err := os.ReadFile(filepath.Clean(path)); err == nil {
+		var existing yaml.Node
+		if err := yaml.Unmarshal(data, &existing); err == nil && len(existing.Content) > 0 {
+			if from, err := configVersion(existing.Content[0]); err == nil && from < CurrentConfigVersion {
+				if _, err := backupConfig(path, data, from); err != nil {
+					return err
+				}
+			}
+			if _, _, err := migrateNode(&existing); err != nil {
+				return err
+			}
+			syncNode(existing.Content[0], &updated, reflect.TypeOf(Config{}))
+			doc = &existing
+			indent = detectIndent(data)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, buf.Bytes(), 0o600)
+}
+
+// detectIndent 取嵌套映射中子键相对父键的最小缩进作为缩进宽度，默认 2
+// 列表元素中的键（- name: x 之后的 desc: y）由列表符号决定位置，不参与计算
+func detectIndent(data []byte) int {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 2
+	}
+	indent := 0
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 {
+					if d := value.Content[0].Column - key.Column; d > 0 && (indent == 0 || d < indent) {
+						indent = d
+					}
+				}
+			}
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(&doc)
+	if indent == 0 {
+		return 2
+	}
+	return indent
+}
+
+// structField 按 yaml 标签查找结构体字段类型
+func structField(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if name == key {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// syncNode 把 src 的内容同步到 dst，尽量复用 dst 中已有的节点以保留注释
+// t 为节点对应的 Go 类型：结构体中未声明的键（用户扩展字段）会被保留，
+// map 和任意类型中 src 不存在的键会被删除
+func syncNode(dst, src *yaml.Node, t reflect.Type) {
+	t = derefType(t)
+	if dst.Kind != src.Kind || dst.Kind == yaml.AliasNode {
+		replaceNode(dst, src)
+		return
+	}
+	switch dst.Kind {
+	case yaml.MappingNode:
+		syncMapping(dst, src, t)
+	case yaml.SequenceNode:
+		syncSequence(dst, src, t)
+	case yaml.ScalarNode:
+		if dst.Value != src.Value || dst.ShortTag() != src.ShortTag() {
+			dst.Value, dst.Tag, dst.Style = src.Value, src.Tag, src.Style
+		}
+	}
+}
+
+// replaceNode 用 src 替换 dst 的内容，保留 dst 上的注释
+func replaceNode(dst, src *yaml.Node) {
+	head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+	*dst = *src
+	if dst.HeadComment == "" {
+		dst.HeadComment = head
+	}
+	if dst.LineComment == "" {
+		dst.LineComment = line
+	}
+	if dst.FootComment == "" {
+		dst.FootComment = foot
+	}
+}
+
+func syncMapping(dst, src *yaml.Node, t reflect.Type) {
+	srcValues := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		srcValues[src.Content[i].Value] = src.Content[i+1]
+	}
+	isStruct := t != nil && t.Kind() == reflect.Struct
+
+	childType := func(key string) (reflect.Type, bool) {
+		switch {
+		case isStruct:
+			return structField(t, key)
+		case t != nil && t.Kind() == reflect.Map:
+			return t.Elem(), true
+		default:
+			return nil, true
+		}
+	}
+
+	// 更新或删除已有的键，保持原有顺序
+	content := make([]*yaml.Node, 0, len(dst.Content))
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, value := dst.Content[i], dst.Content[i+1]
+		ct, known := childType(key.Value)
+		srcValue, ok := srcValues[key.Value]
+		switch {
+		case ok:
+			syncNode(value, srcValue, ct)
+		case !known:
+			// 结构体中未声明的键，原样保留
+		default:
+			continue
+		}
+		seen[key.Value] = true
+		content = append(content, key, value)
+	}
+	// 追加新增的键
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if !seen[src.Content[i].Value] {
+			content = append(content, src.Content[i], src.Content[i+1])
+		}
+	}
+	adoptStyle(dst, src, content)
+}
+
+// adoptStyle 原本为空的流式集合（如 {} / []）有了内容后改用新节点的样式，避免整段写在一行
+func adoptStyle(dst, src *yaml.Node, content []*yaml.Node) {
+	if len(dst.Content) == 0 && len(content) > 0 && dst.Style&yaml.FlowStyle != 0 {
+		dst.Style = src.Style
+	}
+	dst.Content = content
+}
+
+// syncSequence 按值复用原有元素节点（保留元素上的注释），其余使用新节点
+func syncSequence(dst, src *yaml.Node, t reflect.Type) {
+	var elem reflect.Type
+	if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		elem = t.Elem()
+	}
+	used := make([]bool, len(dst.Content))
+	content := make([]*yaml.Node, 0, len(src.Content))
+	for i, item := range src.Content {
+		reused := false
+		if item.Kind == yaml.ScalarNode {
+			for j, old := range dst.Content {
+				if !used[j] && old.Kind == yaml.ScalarNode && old.Value == item.Value {
+					used[j] = true
+					content = append(content, old)
+					reused = true
+					break
+				}
+			}
+		} else if i < len(dst.Content) && !used[i] && dst.Content[i].Kind == item.Kind {
+			used[i] = true
+			syncNode(dst.Content[i], item, elem)
+			content = append(content, dst.Content[i])
+			reused = true
+		}
+		if !reused {
+			content = append(content, item)
+		}
+	}
+	adoptStyle(dst, src, content)
+}
@@ -0,0 +1,185 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"empty", "", 2},
+		{"flat", "version: 1\nplugin_dirs: [a]\n", 2},
+		{"two", "plugins:\n  docker:\n    install: {}\n", 2},
+		{"four", "plugins:\n    docker:\n        install: {}\n", 4},
+		{
+			name: "list of maps before nested mapping",
+			data: "inline_plugins:\n  - name: hi\n    run: echo hi\nplugins:\n  docker: {}\n",
+			want: 2,
+		},
+		{
+			name: "zero-indented list of maps",
+			data: "inline_plugins:\n- name: hi\n  flags:\n  - name: who\n    default: world\nexecutor:\n   shell: /bin/sh\n",
+			want: 3,
+		},
+		{"only lists", "plugin_dirs:\n    - a\n    - b\n", 2},
+		{"comments ignored", "# header\n      # indented comment\nexecutor:\n  shell: /bin/sh\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectIndent([]byte(tt.data)); got != tt.want {
+				t.Errorf("detectIndent() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveConfigKeepsIndentWithListOfMaps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool.yml")
+	original := `version: 1
+inline_plugins:
+  - name: hi
+    run: echo hi
+plugins:
+  docker:
+    install:
+      version: "1"
+`
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "\n  docker:\n    install:\n") {
+		t.Errorf("SaveConfig re-indented the file:\n%s", data)
+	}
+}
+
+func TestWriteFileAtomicFollowsSymlinkAndKeepsMode(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "tool.yml")
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(target, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "tool.yml")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(link, []byte("new\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("symlink was replaced by a regular file")
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != "new\n" {
+		t.Errorf("target content = %q, want new", data)
+	}
+	info, _ = os.Stat(target)
+	if perm := info.Mode().Perm(); perm != 0o644 {
+		t.Errorf("target mode = %o, want 644", perm)
+	}
+}
+
+func TestWriteFileAtomicNewFileUsesPerm(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "new.yml")
+	if err := WriteFileAtomic(path, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("mode = %o, want 600", perm)
+	}
+}
+
+func TestSaveConfigRoundTripsInlinePlugins(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tool.yml")
+	original := fmt.Sprintf(`version: %d
+plugin_dirs: []
+enabled_plugins: []
+plugins: {}
+inline_plugins:
+  - name: hi
+    run: echo "hi $@"
+    flags:
+      - name: who
+        default: world
+      - name: loud
+        type: bool
+        default: false
+      - name: tag
+        default: ""
+      - name: note
+        desc: free text
+`, CurrentConfigVersion)
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.EnabledPlugins = append(cfg.EnabledPlugins, "docker")
+	// 修改已有文件和写入新文件两条路径都要能重新加载
+	fresh := filepath.Join(dir, "fresh.yml")
+	for _, p := range []string{path, fresh} {
+		if err := SaveConfig(p, cfg); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 未声明的 desc/type/default 不写出，声明过的值（包括空字符串默认值）保留
+		for _, unwanted := range []string{`desc: ""`, `type: ""`, "default: null"} {
+			if strings.Contains(string(data), unwanted) {
+				t.Errorf("%s contains %s:\n%s", filepath.Base(p), unwanted, data)
+			}
+		}
+		for _, wanted := range []string{"type: bool", `default: ""`, "desc: free text"} {
+			if !strings.Contains(string(data), wanted) {
+				t.Errorf("%s is missing %s:\n%s", filepath.Base(p), wanted, data)
+			}
+		}
+		reloaded, err := LoadConfig(p)
+		if err != nil {
+			t.Fatalf("reload %s: %v\n%s", filepath.Base(p), err, data)
+		}
+		if note := reloaded.InlinePlugins[0].Flags[3]; note.Default != nil || note.Type != "" {
+			t.Errorf("%s flag note = %+v, want no type and default", filepath.Base(p), note)
+		}
+		if !reflect.DeepEqual(reloaded.InlinePlugins, cfg.InlinePlugins) {
+			t.Errorf("%s inline_plugins = %+v, want %+v", filepath.Base(p), reloaded.InlinePlugins, cfg.InlinePlugins)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultPluginDepth 默认只在插件目录的直接子目录中查找插件
+const DefaultPluginDepth = 1
+
+// DefaultPluginDir 返回默认插件目录 $XDG_DATA_HOME/tool/plugins，未设置时为 ~/.local/share/tool/plugins
+func DefaultPluginDir(env Environ) string {
+	if dir := env.Get("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "tool", "plugins")
+	}
+	return filepath.Join(env.HomeDir(), ".local", "share", "tool", "plugins")
+}
+
+// ExpandHome 把开头的 ~ 或 ~/ 展开为 home
+func ExpandHome(path, home string) string {
+	if home == "" || (path != "~" && !strings.HasPrefix(path, "~/")) {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// ResolvePath 把 ~ 展开为 home，相对路径基于 base 目录转换为绝对路径；base 为空时基于当前目录
+func ResolvePath(path, base, home string) string {
+	path = ExpandHome(path, home)
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	if base != "" {
+		path = filepath.Join(base, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// IsGlobPattern 判断路径是否包含通配符
+func IsGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// ExpandPluginDirs 展开 plugin_dirs 中的 ~（展开为 home）和通配符（例如 ~/work/*/tool-plugins）并去重，
+// 保持配置中的顺序，同一个通配符匹配到的目录按名称排序；不含通配符的目录即使不存在也保留
+func ExpandPluginDirs(dirs []string, home string) []string {
+	var out []string
+	seen := map[string]bool{}
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			out = append(out, dir)
+		}
+	}
+	for _, dir := range dirs {
+		dir = ExpandHome(dir, home)
+		if !IsGlobPattern(dir) {
+			add(dir)
+			continue
+		}
+		matches, _ := filepath.Glob(dir)
+		sort.Strings(matches)
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.IsDir() {
+				add(m)
+			}
+		}
+	}
+	return out
+}
+
+// FindPluginDirs 在 root 下查找插件目录，root 本身包含插件清单（meta.yml 等）时直接返回 root。
+// 包含清单的子目录是插件，不再继续向下查找；其余子目录在 depth 层以内继续查找，
+// 到达 depth 层时作为插件目录返回，由加载时报告缺少清单。隐藏目录会被跳过
+func FindPluginDirs(root string, depth int) ([]string, error) {
+	if hasMetaFile(root) {
+		return []string{root}, nil
+	}
+	if depth < 1 {
+		depth = DefaultPluginDepth
+	}
+	var dirs []string
+	var walk func(dir string, level int) error
+	walk = func(dir string, level int) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			p := filepath.Join(dir, e.Name())
+			if hasMetaFile(p) || level >= depth {
+				dirs = append(dirs, p)
+				continue
+			}
+			// 中间层目录不可读时跳过，不影响其他插件
+			_ = walk(p, level+1)
+		}
+		return nil
+	}
+	if err := walk(root, 1); err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
+func hasMetaFile(dir string) bool {
+	return FindMetaFile(dir) != ""
+}
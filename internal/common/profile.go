@@ -0,0 +1,83 @@
+package common
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// EnvProfile 未指定 --profile 时用于选择 profile 的环境变量
+const EnvProfile = "TOOL_PROFILE"
+
+// ProfileOrigin 返回由 profile 带来的配置项的来源标识
+func ProfileOrigin(name string) string {
+	return "profile:" + name
+}
+
+// mergeProfile 合并各配置层中的同名 profile，规则与基础配置一致
+func (lc *LayeredConfig) mergeProfile(name string, src Profile, origin string) {
+	cur := lc.Cfg.Profiles[name]
+	prefix := "profiles." + name
+	if src.Desc != "" {
+		cur.Desc = src.Desc
+		lc.Origins[prefix+".desc"] = origin
+	}
+	for _, n := range src.EnabledPlugins {
+		if !slices.Contains(cur.EnabledPlugins, n) {
+			cur.EnabledPlugins = append(cur.EnabledPlugins, n)
+		}
+	}
+	if len(src.EnabledPlugins) > 0 {
+		lc.Origins[prefix+".enabled_plugins"] = origin
+	}
+	for pname, pc := range src.Plugins {
+		if cur.Plugins == nil {
+			cur.Plugins = map[string]PluginConfig{}
+		}
+		pcur := cur.Plugins[pname]
+		pprefix := prefix + ".plugins." + pname
+		pcur.Install = lc.mergeFlags(pcur.Install, pc.Install, pprefix+".install", origin)
+		pcur.Uninstall = lc.mergeFlags(pcur.Uninstall, pc.Uninstall, pprefix+".uninstall", origin)
+		cur.Plugins[pname] = pcur
+	}
+	lc.Cfg.Profiles[name] = cur
+}
+
+// ProfileNames 返回已定义的 profile 名称（排序后）
+func (cfg *Config) ProfileNames() []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile 把 profile 叠加到合并后的配置上，只修改内存中的配置：
+//   - enabled_plugins 追加到基础列表并去重
+//   - plugins 按 flag 覆盖基础配置
+func (lc *LayeredConfig) ApplyProfile(name string) error {
+	p, ok := lc.Cfg.Profiles[name]
+	if !ok {
+		if names := lc.Cfg.ProfileNames(); len(names) > 0 {
+			return fmt.Errorf("profile '%s' not defined, available: %s", name, strings.Join(names, ", "))
+		}
+		return fmt.Errorf("profile '%s' not defined, no profiles configured", name)
+	}
+	origin := ProfileOrigin(name)
+	lc.appendList("enabled_plugins", &lc.Cfg.EnabledPlugins, p.EnabledPlugins, origin)
+	if lc.Cfg.Plugins == nil {
+		lc.Cfg.Plugins = map[string]PluginConfig{}
+	}
+	for pname, pc := range p.Plugins {
+		cur := lc.Cfg.Plugins[pname]
+		prefix := "plugins." + pname
+		cur.Install = lc.mergeFlags(cur.Install, pc.Install, prefix+".install", origin)
+		cur.Uninstall = lc.mergeFlags(cur.Uninstall, pc.Uninstall, prefix+".uninstall", origin)
+		lc.Cfg.Plugins[pname] = cur
+	}
+	lc.finalizeListOrigins()
+	lc.Profile = name
+	return nil
+}
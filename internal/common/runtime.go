@@ -0,0 +1,84 @@
+package common
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bookandmusic/tool/internal/logger"
+)
+
+// Runtime 一次命令行调用的运行时状态，由 cmd.App 创建后注入服务和插件加载器。
+// 每个 App 持有独立的 Runtime，同一进程中的多个 App 可以同时运行
+type Runtime struct {
+	Cfg     *Config
+	Logger  logger.Logger // 输出写入 Logger.Writer()，诊断写入 Logger.ErrWriter()
+	Stdin   io.Reader     // 插件和交互命令的标准输入
+	CfgPath string        // 可写的用户级配置文件
+	// Layered 分层加载的结果，记录每个配置项的来源
+	Layered *LayeredConfig
+	// Output 列表命令的输出格式（--output），Columns 为 --columns 选择的列
+	Output  string
+	Columns []string
+	// Color 命令结果（表格等）是否着色，由 --color 和结果流是否为终端决定
+	Color bool
+	// CachePath 插件元数据缓存文件，NoCache 为 true 时不读写缓存（--no-cache）
+	CachePath string
+	NoCache   bool
+	// HostRoot 探测主机信息（/etc/os-release 等）时使用的根目录，为空表示 "/"
+	HostRoot string
+	// Env 插件进程、${env:...}、$EDITOR 和默认路径使用的环境变量
+	Env Environ
+	// Secrets 本次调用解析出的机密值，日志和 dry-run 输出前隐藏
+	Secrets *Secrets
+}
+
+// Environ KEY=VALUE 形式的环境变量，nil 表示当前进程的环境变量
+type Environ []string
+
+// Lookup 读取 key，同名变量以最后一个为准，与子进程看到的值一致
+func (e Environ) Lookup(key string) (string, bool) {
+	if e == nil {
+		return os.LookupEnv(key)
+	}
+	for i := len(e) - 1; i >= 0; i-- {
+		if k, v, ok := strings.Cut(e[i], "="); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Get 读取 key，未设置时返回空字符串
+func (e Environ) Get(key string) string {
+	v, _ := e.Lookup(key)
+	return v
+}
+
+// List 返回传给子进程的环境变量
+func (e Environ) List() []string {
+	if e == nil {
+		return os.Environ()
+	}
+	return e
+}
+
+// HomeDir 返回 $HOME，未设置时使用 os.UserHomeDir
+func (e Environ) HomeDir() string {
+	if home := e.Get("HOME"); home != "" {
+		return home
+	}
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// 列表命令支持的输出格式
+const (
+	OutputTable    = "table"
+	OutputJSON     = "json"
+	OutputYAML     = "yaml"
+	OutputCSV      = "csv"
+	OutputMarkdown = "markdown"
+)
+
+var OutputFormats = []string{OutputTable, OutputJSON, OutputYAML, OutputCSV, OutputMarkdown}
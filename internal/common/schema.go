@@ -0,0 +1,32 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/bookandmusic/tool/internal/schema"
+)
+
+var (
+	metaSchema   *schema.Schema
+	configSchema *schema.Schema
+	schemaOnce   sync.Once
+)
+
+func initSchemas() {
+	metaSchema = schema.Generate(Meta{}, "https://github.com/bookandmusic/tool/schema/meta.json", "tool plugin meta.yml")
+	configSchema = schema.Generate(Config{}, "https://github.com/bookandmusic/tool/schema/config.json", "tool config tool.yml")
+	// tool.yml 允许 x- 开头的扩展键，写回时原样保留
+	configSchema.AllowExtensions(ExtensionPrefix)
+}
+
+// MetaSchema 返回由 Meta 结构体生成的 meta.yml JSON Schema
+func MetaSchema() *schema.Schema {
+	schemaOnce.Do(initSchemas)
+	return metaSchema
+}
+
+// ConfigSchema 返回由 Config 结构体生成的 tool.yml JSON Schema
+func ConfigSchema() *schema.Schema {
+	schemaOnce.Do(initSchemas)
+	return configSchema
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+const secretMask = "******"
+
+// minSecretLen 短于该长度的值不登记为机密，否则单个字符会在所有输出中被隐藏
+const minSecretLen = 4
+
+// Secrets 一次调用中解析出的机密值（${env|file|cmd:...}），可并发访问；
+// nil 表示没有机密值，Mask 原样返回
+type Secrets struct {
+	mu     sync.RWMutex
+	values map[string]struct{}
+}
+
+// NewSecrets 创建空的机密值集合
+func NewSecrets() *Secrets {
+	return &Secrets{values: map[string]struct{}{}}
+}
+
+// Add 记录机密值，之后经过 Mask 的输出（日志、dry-run 等）都会隐藏它，返回原值便于链式使用；
+// 插件自身的输出不做处理
+func (s *Secrets) Add(value string) string {
+	if s == nil || len(strings.TrimSpace(value)) < minSecretLen {
+		return value
+	}
+	s.mu.Lock()
+	s.values[value] = struct{}{}
+	s.mu.Unlock()
+	return value
+}
+
+// Mask 把文本中已登记的机密值替换为 ******
+func (s *Secrets) Mask(text string) string {
+	if s == nil {
+		return text
+	}
+	s.mu.RLock()
+	list := make([]string, 0, len(s.values))
+	for secret := range s.values {
+		list = append(list, secret)
+	}
+	s.mu.RUnlock()
+	// 先替换较长的值，避免互相包含的机密只被部分隐藏
+	sort.Slice(list, func(i, j int) bool { return len(list[i]) > len(list[j]) })
+	for _, secret := range list {
+		text = strings.ReplaceAll(text, secret, secretMask)
+	}
+	return text
+}
@@ -10,3 +10,8 @@ type CmdParams struct {
 type Service interface {
 	Handler(cmd *cobra.Command, cmdParams *CmdParams, args []string, kwargs map[string]any) error
 }
+
+// CommandBuilder 由执行外部命令的服务实现，返回将要执行的命令参数而不真正执行，用于 dry-run
+type CommandBuilder interface {
+	BuildCommand(cmd *cobra.Command, cmdParams *CmdParams, args []string, kwargs map[string]any) ([]string, error)
+}
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// Version 工具版本号，可通过 -ldflags "-X github.com/bookandmusic/tool/internal/common.Version=v0.3.0" 注入
+var Version = ""
+
+// DevVersion 无法确定版本时使用的占位版本（本地开发构建）
+const DevVersion = "dev"
+
+// ToolVersion 返回工具自身版本
+// 优先级：ldflags 注入 > 构建信息中的模块版本 > dev
+func ToolVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		// 未打 tag 的仓库会得到 v0.0.0-<时间>-<提交> 形式的伪版本，同样视为开发构建
+		if v := info.Main.Version; v != "" && v != "(devel)" && !strings.HasPrefix(v, "v0.0.0-") {
+			return v
+		}
+	}
+	return DevVersion
+}
@@ -0,0 +1,281 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/utils"
+)
+
+var (
+	namePattern     = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	flagNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	// yaml.v3 的错误信息形如 "line 6: field description not found in type common.Meta"
+	lineErrPattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+)
+
+// Reserved 插件不能使用的名称
+type Reserved struct {
+	Builtins     map[string]bool // 内置命令
+	SoftCommands map[string]bool // 内置 soft 命令自身的子命令，只限制 soft 插件
+}
+
+// linter 单个插件目录的检查上下文
+type linter struct {
+	file     string
+	dir      string
+	reserved Reserved
+	diags    []Diagnostic
+}
+
+func (l *linter) report(node *yaml.Node, sev Severity, format string, args ...any) {
+	d := Diagnostic{File: l.file, Severity: sev, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		d.Line, d.Column = node.Line, node.Column
+	}
+	l.diags = append(l.diags, d)
+}
+
+func (l *linter) errorf(node *yaml.Node, format string, args ...any) {
+	l.report(node, SeverityError, format, args...)
+}
+
+func (l *linter) warnf(node *yaml.Node, format string, args ...any) {
+	l.report(node, SeverityWarning, format, args...)
+}
+
+// reportYAMLError 把 yaml 错误拆分为带行号的诊断
+func (l *linter) reportYAMLError(err error, fallback *yaml.Node) {
+	var msgs []string
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+	for _, msg := range msgs {
+		if m := lineErrPattern.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			l.diags = append(l.diags, Diagnostic{File: l.file, Line: line, Severity: SeverityError, Message: m[2]})
+			continue
+		}
+		l.errorf(fallback, "%s", msg)
+	}
+}
+
+// Dir 检查插件目录下的清单（meta.yml、meta.yaml、meta.json 或 meta.toml），reserved 为插件不能使用的名称
+func Dir(dir string, reserved Reserved) []Diagnostic {
+	l := &linter{file: common.FindMetaFile(dir), dir: dir, reserved: reserved}
+	if l.file == "" {
+		l.file = filepath.Join(dir, common.MetaFileNames[0])
+		l.errorf(nil, "no plugin manifest found")
+		return l.diags
+	}
+	// TOML 清单转换为 YAML 后检查，诊断的行号与原文件不对应，只保留文件名
+	if common.IsTOMLMeta(l.file) {
+		defer func() {
+			for i := range l.diags {
+				l.diags[i].Line, l.diags[i].Column = 0, 0
+			}
+		}()
+	}
+	data, err := common.ReadMetaFile(l.file)
+	if err != nil {
+		l.errorf(nil, "cannot read plugin manifest: %v", err)
+		return l.diags
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		l.reportYAMLError(err, nil)
+		return l.diags
+	}
+	root := documentRoot(&doc)
+	if root == nil || root.Kind != yaml.MappingNode {
+		l.errorf(root, "plugin manifest must be a mapping")
+		return l.diags
+	}
+
+	// 结构校验与加载时使用同一份 Schema：未知字段、类型不符、缺少必填字段均视为错误
+	for _, e := range common.MetaSchema().ValidateNode(&doc) {
+		msg := e.Message
+		if e.Path != "" {
+			msg = e.Path + ": " + msg
+		}
+		l.diags = append(l.diags, Diagnostic{File: l.file, Line: e.Line, Column: e.Column, Severity: SeverityError, Message: msg})
+	}
+	// 其余检查基于尽力解码的结果，解码错误已由 Schema 报告
+	var meta common.Meta
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		l.reportYAMLError(err, nil)
+	}
+
+	l.checkHeader(root, &meta)
+	l.checkExec(root, &meta)
+	l.checkCommands(root, &meta)
+	_, flagsNode := mappingValue(root, "flags")
+	l.checkFlags(flagsNode)
+
+	sortDiagnostics(l.diags)
+	return l.diags
+}
+
+func (l *linter) checkHeader(root *yaml.Node, meta *common.Meta) {
+	_, nameNode := mappingValue(root, "name")
+	switch {
+	case nameNode == nil:
+		// 缺少 name 已由 Schema 报告
+	case !namePattern.MatchString(meta.Name):
+		l.errorf(nameNode, "invalid plugin name %q: use lowercase letters, digits, '-' or '_'", meta.Name)
+	case l.reserved.Builtins[meta.Name]:
+		l.errorf(nameNode, "plugin name %q collides with a builtin command", meta.Name)
+	case meta.Type == common.Soft && l.reserved.SoftCommands[meta.Name]:
+		l.errorf(nameNode, "soft plugin name %q collides with the builtin 'soft %s' command", meta.Name, meta.Name)
+	}
+
+	if _, nsNode := mappingValue(root, "namespace"); nsNode != nil && !namePattern.MatchString(meta.Namespace) {
+		l.errorf(nsNode, "invalid namespace %q: use lowercase letters, digits, '-' or '_'", meta.Namespace)
+	}
+
+	if _, descNode := mappingValue(root, "desc"); descNode == nil {
+		l.warnf(root, "missing 'desc', a generic description will be shown in help")
+	}
+
+	if _, versionNode := mappingValue(root, "version"); versionNode != nil {
+		if _, err := utils.ParseVersion(meta.Version); err != nil {
+			l.errorf(versionNode, "%v", err)
+		}
+	}
+	if _, reqNode := mappingValue(root, "requires_tool"); reqNode != nil {
+		if _, err := utils.CheckVersionConstraint("0.0.0", meta.RequiresTool); err != nil {
+			l.errorf(reqNode, "invalid requires_tool constraint %q: %v", meta.RequiresTool, err)
+		}
+	}
+}
+
+func (l *linter) checkExec(root *yaml.Node, meta *common.Meta) {
+	_, execNode := mappingValue(root, "exec")
+	if execNode == nil {
+		return
+	}
+	path := filepath.Join(l.dir, filepath.Clean("/"+meta.Exec))
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		l.errorf(execNode, "exec %q not found in plugin directory", meta.Exec)
+	case info.IsDir():
+		l.errorf(execNode, "exec %q is a directory", meta.Exec)
+	case meta.ExecType == "" && info.Mode().Perm()&0o111 == 0:
+		// 未指定 exec_type 时直接执行脚本，需要可执行权限
+		l.errorf(execNode, "exec %q is not executable; chmod +x it or set exec_type", meta.Exec)
+	}
+}
+
+func (l *linter) checkCommands(root *yaml.Node, meta *common.Meta) {
+	_, commandsNode := mappingValue(root, "commands")
+	seen := map[string]bool{}
+	for _, item := range sequenceItems(commandsNode) {
+		_, nameNode := mappingValue(item, "name")
+		name := scalar(nameNode)
+		switch {
+		case name == "":
+			// 缺少 name 已由 Schema 报告
+		case seen[name]:
+			l.errorf(nameNode, "duplicate command %q", name)
+		}
+		seen[name] = true
+		_, flagsNode := mappingValue(item, "flags")
+		l.checkFlags(flagsNode)
+	}
+
+	if meta.Type == common.Soft {
+		// 未声明 commands 时定位到清单根节点
+		at := commandsNode
+		if at == nil {
+			at = root
+		}
+		for _, required := range []string{"install", "uninstall"} {
+			if !seen[required] {
+				l.errorf(at, "soft plugin must define a '%s' command", required)
+			}
+		}
+	}
+}
+
+// checkFlags 检查同一作用域内的 flags：名称合法且唯一、类型声明合法、默认值与类型匹配
+func (l *linter) checkFlags(flagsNode *yaml.Node) {
+	seen := map[string]bool{}
+	for _, item := range sequenceItems(flagsNode) {
+		_, nameNode := mappingValue(item, "name")
+		name := scalar(nameNode)
+		switch {
+		case name == "":
+			// 缺少 name 已由 Schema 报告
+		case !flagNamePattern.MatchString(name):
+			l.errorf(nameNode, "invalid flag name %q", name)
+		case name == "help":
+			l.errorf(nameNode, "flag name 'help' is reserved")
+		case seen[name]:
+			l.errorf(nameNode, "duplicate flag %q", name)
+		}
+		seen[name] = true
+
+		_, typeNode := mappingValue(item, "type")
+		_, defaultNode := mappingValue(item, "default")
+		l.checkFlagDefault(name, typeNode, defaultNode, item)
+	}
+}
+
+// yamlTags 每种 flag 类型允许的默认值 YAML 标签
+var yamlTags = map[string][]string{
+	common.FlagString: {"!!str"},
+	common.FlagBool:   {"!!bool"},
+	common.FlagInt:    {"!!int"},
+	common.FlagFloat:  {"!!float", "!!int"},
+}
+
+func (l *linter) checkFlagDefault(name string, typeNode, defaultNode, item *yaml.Node) {
+	if defaultNode == nil || defaultNode.ShortTag() == "!!null" {
+		l.warnf(item, "flag %q has no default value", name)
+		return
+	}
+	if defaultNode.Kind != yaml.ScalarNode {
+		l.errorf(defaultNode, "default of flag %q must be a scalar value", name)
+		return
+	}
+	if typeNode == nil {
+		return
+	}
+	declared := scalar(typeNode)
+	tags, ok := yamlTags[declared]
+	if !ok {
+		return // 非法类型已由 Schema 报告
+	}
+	tag := defaultNode.ShortTag()
+	for _, t := range tags {
+		if t == tag {
+			return
+		}
+	}
+	l.errorf(defaultNode, "default %q of flag %q does not match declared type %s", defaultNode.Value, name, declared)
+}
+
+// Dirs 展开待检查的目录：包含 meta.yml 的目录直接检查，否则按与加载插件相同的规则向下查找 depth 层
+func Dirs(paths []string, depth int) ([]string, error) {
+	var dirs []string
+	for _, p := range paths {
+		found, err := common.FindPluginDirs(p, depth)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, found...)
+	}
+	return dirs, nil
+}
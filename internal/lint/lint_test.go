@@ -0,0 +1,377 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testReserved = Reserved{
+	Builtins:     map[string]bool{"plugin": true},
+	SoftCommands: map[string]bool{"list": true},
+}
+
+// fixture 一个插件目录，files 为文件名到内容的映射，.sh 文件带可执行权限
+type fixture struct {
+	name  string
+	files map[string]string
+	want  []string // Diagnostic.String()，文件名只保留 base name
+}
+
+// command 返回最小的 command 插件目录，body 放在清单开头以保持行号不变
+func command(body string, extra ...string) map[string]string {
+	files := map[string]string{
+		"meta.yml": body + "type: command\nexec: run.sh\n",
+		"run.sh":   "#!/bin/sh\n",
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		files[extra[i]] = extra[i+1]
+	}
+	return files
+}
+
+// soft 返回 soft 插件目录，body 需要声明 type: soft
+func soft(body string) map[string]string {
+	return map[string]string{
+		"meta.yml": body + "exec: run.sh\n",
+		"run.sh":   "#!/bin/sh\n",
+	}
+}
+
+func writeFixture(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "plugin")
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		mode := os.FileMode(0o644)
+		if strings.HasSuffix(name, ".sh") {
+			mode = 0o755
+		}
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func runFixtures(t *testing.T, fixtures []fixture) {
+	t.Helper()
+	for _, f := range fixtures {
+		t.Run(f.name, func(t *testing.T) {
+			dir := writeFixture(t, f.files)
+			var got []string
+			for _, d := range Dir(dir, testReserved) {
+				d.File = filepath.Base(d.File)
+				got = append(got, d.String())
+			}
+			if !reflect.DeepEqual(got, f.want) {
+				t.Errorf("diagnostics =\n  %s\nwant\n  %s", strings.Join(got, "\n  "), strings.Join(f.want, "\n  "))
+			}
+		})
+	}
+}
+
+func TestLintManifest(t *testing.T) {
+	runFixtures(t, []fixture{
+		{
+			name: "clean",
+			files: map[string]string{
+				"run.sh": "#!/bin/sh\n",
+				"meta.yml": `name: hello
+desc: Say hello
+version: 1.0.0
+requires_tool: ">=0.1"
+type: command
+exec: run.sh
+flags:
+  - name: who
+    default: world
+`,
+			},
+		},
+		{
+			name:  "missing manifest",
+			files: map[string]string{"run.sh": "#!/bin/sh\n"},
+			want:  []string{"meta.yml: error: no plugin manifest found"},
+		},
+		{
+			name:  "syntax error",
+			files: map[string]string{"meta.yml": "name: hello\ndesc: x\n  bad: indent\n"},
+			want:  []string{"meta.yml:3: error: mapping values are not allowed in this context"},
+		},
+		{
+			name:  "not a mapping",
+			files: map[string]string{"meta.yml": "- hello\n"},
+			want:  []string{"meta.yml:1:1: error: plugin manifest must be a mapping"},
+		},
+		{
+			name: "unknown field",
+			files: command(`name: hello
+desc: Say hello
+description: typo
+`),
+			want: []string{`meta.yml:3:1: error: description: unknown field "description"`},
+		},
+		{
+			name:  "missing required fields",
+			files: map[string]string{"meta.yml": "name: hello\ndesc: x\ntype: command\n"},
+			want:  []string{`meta.yml:1:1: error: missing required field "exec"`},
+		},
+		{
+			name:  "missing desc",
+			files: command("name: hello\n"),
+			want:  []string{"meta.yml:1:1: warning: missing 'desc', a generic description will be shown in help"},
+		},
+		{
+			name: "toml drops positions",
+			files: map[string]string{"meta.toml": `name = "Hello"
+desc = "Say hello"
+type = "command"
+exec = "run.sh"
+`, "run.sh": "#!/bin/sh\n"},
+			want: []string{`meta.toml: error: invalid plugin name "Hello": use lowercase letters, digits, '-' or '_'`},
+		},
+	})
+}
+
+func TestLintHeader(t *testing.T) {
+	runFixtures(t, []fixture{
+		{
+			name:  "invalid name",
+			files: command("name: Hello\ndesc: x\n"),
+			want:  []string{`meta.yml:1:7: error: invalid plugin name "Hello": use lowercase letters, digits, '-' or '_'`},
+		},
+		{
+			name:  "builtin collision",
+			files: command("name: plugin\ndesc: x\n"),
+			want:  []string{`meta.yml:1:7: error: plugin name "plugin" collides with a builtin command`},
+		},
+		{
+			name: "soft command collision",
+			files: soft(`name: list
+desc: x
+type: soft
+commands:
+  - name: install
+  - name: uninstall
+`),
+			want: []string{`meta.yml:1:7: error: soft plugin name "list" collides with the builtin 'soft list' command`},
+		},
+		{
+			name:  "command plugin may reuse soft command name",
+			files: command("name: list\ndesc: x\n"),
+		},
+		{
+			name:  "invalid namespace",
+			files: command("name: hello\nnamespace: Acme\ndesc: x\n"),
+			want:  []string{`meta.yml:2:12: error: invalid namespace "Acme": use lowercase letters, digits, '-' or '_'`},
+		},
+		{
+			name:  "invalid version",
+			files: command("name: hello\ndesc: x\nversion: one\n"),
+			want:  []string{`meta.yml:3:10: error: invalid version: "one"`},
+		},
+		{
+			name:  "invalid requires_tool",
+			files: command("name: hello\ndesc: x\nrequires_tool: \"=>1\"\n"),
+			want:  []string{`meta.yml:3:16: error: invalid requires_tool constraint "=>1": invalid version: ">1"`},
+		},
+	})
+}
+
+func TestLintExec(t *testing.T) {
+	runFixtures(t, []fixture{
+		{
+			name:  "exec not found",
+			files: map[string]string{"meta.yml": "name: hello\ndesc: x\nexec: run.sh\ntype: command\n"},
+			want:  []string{`meta.yml:3:7: error: exec "run.sh" not found in plugin directory`},
+		},
+		{
+			name:  "exec is a directory",
+			files: map[string]string{"meta.yml": "name: hello\ndesc: x\nexec: bin\ntype: command\n", "bin/": ""},
+			want:  []string{`meta.yml:3:7: error: exec "bin" is a directory`},
+		},
+		{
+			name:  "exec not executable",
+			files: map[string]string{"meta.yml": "name: hello\ndesc: x\nexec: run.py\ntype: command\n", "run.py": ""},
+			want:  []string{`meta.yml:3:7: error: exec "run.py" is not executable; chmod +x it or set exec_type`},
+		},
+		{
+			name:  "exec_type allows non-executable script",
+			files: map[string]string{"meta.yml": "name: hello\ndesc: x\nexec: run.py\nexec_type: python\ntype: command\n", "run.py": ""},
+		},
+		{
+			name:  "exec cannot escape plugin directory",
+			files: map[string]string{"meta.yml": "name: hello\ndesc: x\nexec: ../outside.sh\ntype: command\n"},
+			want:  []string{`meta.yml:3:7: error: exec "../outside.sh" not found in plugin directory`},
+		},
+	})
+}
+
+func TestLintCommands(t *testing.T) {
+	runFixtures(t, []fixture{
+		{
+			name:  "soft plugin without commands",
+			files: soft("name: hello\ndesc: x\ntype: soft\n"),
+			want: []string{
+				"meta.yml:1:1: error: soft plugin must define a 'install' command",
+				"meta.yml:1:1: error: soft plugin must define a 'uninstall' command",
+			},
+		},
+		{
+			name: "duplicate command",
+			files: command(`name: hello
+desc: x
+commands:
+  - name: run
+  - name: run
+`),
+			want: []string{`meta.yml:5:11: error: duplicate command "run"`},
+		},
+		{
+			name: "soft plugin without install and uninstall",
+			files: soft(`name: hello
+desc: x
+type: soft
+commands:
+  - name: run
+`),
+			want: []string{
+				"meta.yml:5:3: error: soft plugin must define a 'install' command",
+				"meta.yml:5:3: error: soft plugin must define a 'uninstall' command",
+			},
+		},
+	})
+}
+
+func TestLintFlags(t *testing.T) {
+	runFixtures(t, []fixture{
+		{
+			name: "flag names",
+			files: command(`name: hello
+desc: x
+flags:
+  - name: -bad
+    default: x
+  - name: help
+    default: x
+  - name: who
+    default: x
+  - name: who
+    default: y
+`),
+			want: []string{
+				`meta.yml:4:11: error: invalid flag name "-bad"`,
+				"meta.yml:6:11: error: flag name 'help' is reserved",
+				`meta.yml:10:11: error: duplicate flag "who"`,
+			},
+		},
+		{
+			name: "flags are scoped per command",
+			files: command(`name: hello
+desc: x
+flags:
+  - name: who
+    default: x
+commands:
+  - name: run
+    flags:
+      - name: who
+        default: x
+`),
+		},
+		{
+			name: "missing default",
+			files: command(`name: hello
+desc: x
+flags:
+  - name: who
+  - name: where
+    default: ~
+`),
+			want: []string{
+				`meta.yml:4:5: warning: flag "who" has no default value`,
+				`meta.yml:5:5: warning: flag "where" has no default value`,
+			},
+		},
+		{
+			name: "non-scalar default",
+			files: command(`name: hello
+desc: x
+flags:
+  - name: who
+    default: [a, b]
+`),
+			want: []string{`meta.yml:5:14: error: default of flag "who" must be a scalar value`},
+		},
+		{
+			name: "default type mismatch",
+			files: command(`name: hello
+desc: x
+flags:
+  - name: count
+    type: int
+    default: many
+  - name: force
+    type: bool
+    default: "true"
+  - name: ratio
+    type: float
+    default: 1
+  - name: label
+    type: string
+    default: "1"
+`),
+			want: []string{
+				`meta.yml:6:14: error: default "many" of flag "count" does not match declared type int`,
+				`meta.yml:9:14: error: default "true" of flag "force" does not match declared type bool`,
+			},
+		},
+	})
+}
+
+func TestDirs(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a", "meta.yml"), "name: a\n")
+	writeFile(t, filepath.Join(root, "group", "b", "meta.yml"), "name: b\n")
+	writeFile(t, filepath.Join(root, ".git", "c", "meta.yml"), "name: c\n")
+
+	dirs, err := Dirs([]string{root}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(root, "a"), filepath.Join(root, "group", "b")}
+	if !reflect.DeepEqual(dirs, want) {
+		t.Errorf("Dirs = %v, want %v", dirs, want)
+	}
+
+	// 直接指定插件目录时不再向下查找
+	dirs, err = Dirs([]string{filepath.Join(root, "a")}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dirs, want[:1]) {
+		t.Errorf("Dirs(plugin dir) = %v, want %v", dirs, want[:1])
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// --color 的取值
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// ColorEnabled 判断输出到 w 时是否使用颜色：
+// auto 模式下只有 w 是终端、未设置 NO_COLOR 且 TERM 不为 dumb 时才启用，环境变量通过 lookupEnv 读取
+func ColorEnabled(mode string, w io.Writer, lookupEnv func(key string) (string, bool)) (bool, error) {
+	switch mode {
+	case ColorAlways:
+		return true, nil
+	case ColorNever:
+		return false, nil
+	case "", ColorAuto:
+		if _, ok := lookupEnv("NO_COLOR"); ok {
+			return false, nil
+		}
+		if term, _ := lookupEnv("TERM"); term == "dumb" {
+			return false, nil
+		}
+		return IsTerminal(w), nil
+	default:
+		return false, fmt.Errorf("unknown color mode %q, expected %s, %s or %s", mode, ColorAuto, ColorAlways, ColorNever)
+	}
+}
+
+// IsTerminal 判断 w 是否为终端
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// colorFunc 返回始终输出颜色的函数，忽略 fatih/color 根据 stdout 自动探测的全局开关，
+// 是否着色只由 TextFormatter.Color 决定
+func colorFunc(attr color.Attribute) func(a ...interface{}) string {
+	c := color.New(attr)
+	c.EnableColor()
+	return c.SprintFunc()
+}
@@ -3,34 +3,49 @@ package logger
 import (
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
 )
 
-// NewConsoleLogger 创建控制台 Logger
-// 允许传入 io.Writer，例如 os.Stdout / 文件 / buffer
-func NewConsoleLogger(out io.Writer, debug bool) Logger {
-	console := &ConsoleLogger{
-		debugEnabled: debug,
-		out:          out,
+// NewLogger 创建使用指定 Formatter 的 Logger，低于 level 的日志被丢弃
+// out 接收 Print 的命令结果，diag 接收日志，两者可以相同，例如同一个文件或 buffer
+func NewLogger(out, diag io.Writer, level Level, formatter Formatter) Logger {
+	return &ConsoleLogger{
+		level:     level,
+		out:       out,
+		diag:      diag,
+		formatter: formatter,
+		mu:        &sync.Mutex{},
 	}
-	return console
 }
 
 var (
-	timeColor    = color.New(color.FgWhite).SprintFunc() // 时间统一颜色
-	infoColor    = color.New(color.FgCyan).SprintFunc()
-	successColor = color.New(color.FgGreen).SprintFunc()
-	warnColor    = color.New(color.FgYellow).SprintFunc()
-	errorColor   = color.New(color.FgRed).SprintFunc()
-	debugColor   = color.New(color.FgHiBlack).SprintFunc()
+	timeColor    = colorFunc(color.FgWhite) // 时间统一颜色
+	infoColor    = colorFunc(color.FgCyan)
+	successColor = colorFunc(color.FgGreen)
+	warnColor    = colorFunc(color.FgYellow)
+	errorColor   = colorFunc(color.FgRed)
+	debugColor   = colorFunc(color.FgHiBlack)
+
+	labelColors = map[string]func(a ...interface{}) string{
+		LabelInfo:    infoColor,
+		LabelSuccess: successColor,
+		LabelWarn:    warnColor,
+		LabelError:   errorColor,
+	}
 )
 
-// ConsoleLogger 控制台输出实现
+// ConsoleLogger 输出到单个 io.Writer 的实现，格式由 Formatter 决定
 type ConsoleLogger struct {
-	debugEnabled bool
-	out          io.Writer
+	level     Level
+	out       io.Writer
+	diag      io.Writer
+	formatter Formatter
+	fields    []Field
+	mu        *sync.Mutex // With 派生的 Logger 共用，保证多行输出不交错
 }
 
 // Writer 提供给外部库使用，例如 tablewriter
@@ -38,46 +53,66 @@ func (c *ConsoleLogger) Writer() io.Writer {
 	return c.out
 }
 
-// containsPlaceholder 判断 msg 是否包含格式化占位符
-func containsPlaceholder(msg string) bool {
-	_, err := fmt.Fprintf(io.Discard, msg, nil)
-	return err == nil
+// ErrWriter 日志输出的诊断流
+func (c *ConsoleLogger) ErrWriter() io.Writer {
+	return c.diag
+}
+
+// With 返回附带字段的 Logger，原 Logger 不受影响
+func (c *ConsoleLogger) With(kv ...interface{}) Logger {
+	child := *c
+	child.fields = mergeFields(c.fields, fieldsFromKV(kv))
+	return &child
+}
+
+// Enabled 判断某个级别的日志是否会输出
+func (c *ConsoleLogger) Enabled(level Level) bool {
+	return level >= c.level
 }
 
-// formatMessage 增加时间戳、对齐级别标志和颜色
-func (c *ConsoleLogger) formatMessage(level string, colorFunc func(a ...interface{}) string, msg string, args ...interface{}) string {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	var formattedMsg string
-	if len(args) == 0 || !containsPlaceholder(msg) {
-		formattedMsg = msg + fmt.Sprint(args...)
-	} else {
-		formattedMsg = fmt.Sprintf(msg, args...)
+func (c *ConsoleLogger) log(level Level, label, format string, args ...interface{}) {
+	if !c.Enabled(level) {
+		return
+	}
+	entry := &Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Label:   label,
+		Message: strings.TrimRight(fmt.Sprintf(format, args...), "\n"),
+		Fields:  c.fields,
 	}
-	return fmt.Sprintf("%s %s %s", timeColor("["+timestamp+"]"), colorFunc(level), formattedMsg)
+	line := c.formatter.Format(entry)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintln(c.diag, line)
 }
 
-func (c *ConsoleLogger) Info(msg string, args ...interface{}) {
-	fmt.Fprintln(c.out, c.formatMessage("INFO", infoColor, msg, args...))
+func (c *ConsoleLogger) Trace(format string, args ...interface{}) {
+	c.log(LevelTrace, LabelTrace, format, args...)
 }
 
-func (c *ConsoleLogger) Success(msg string, args ...interface{}) {
-	fmt.Fprintln(c.out, c.formatMessage("SUCCESS", successColor, msg, args...))
+func (c *ConsoleLogger) Debug(format string, args ...interface{}) {
+	c.log(LevelDebug, LabelDebug, format, args...)
 }
 
-func (c *ConsoleLogger) Warning(msg string, args ...interface{}) {
-	fmt.Fprintln(c.out, c.formatMessage("WARN", warnColor, msg, args...))
+func (c *ConsoleLogger) Info(format string, args ...interface{}) {
+	c.log(LevelInfo, LabelInfo, format, args...)
 }
 
-func (c *ConsoleLogger) Error(msg string, args ...interface{}) {
-	fmt.Fprintln(c.out, c.formatMessage("ERROR", errorColor, msg, args...))
+func (c *ConsoleLogger) Success(format string, args ...interface{}) {
+	c.log(LevelInfo, LabelSuccess, format, args...)
 }
 
-func (c *ConsoleLogger) Debug(args ...interface{}) {
-	if c.debugEnabled {
-		fmt.Fprint(c.out, debugColor(fmt.Sprint(args...)))
-	}
+func (c *ConsoleLogger) Warning(format string, args ...interface{}) {
+	c.log(LevelWarn, LabelWarn, format, args...)
+}
+
+func (c *ConsoleLogger) Error(format string, args ...interface{}) {
+	c.log(LevelError, LabelError, format, args...)
 }
 
 func (c *ConsoleLogger) Print(args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	fmt.Fprint(c.out, args...)
 }
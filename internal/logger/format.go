@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 日志输出格式
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// KeyComponent 组件字段，text 格式下显示为 [PLUGIN] 这样的前缀
+const KeyComponent = "component"
+
+// Field 结构化字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// 日志记录的显示标签，Success 属于 info 级别但单独标记
+const (
+	LabelTrace   = "TRACE"
+	LabelDebug   = "DEBUG"
+	LabelInfo    = "INFO"
+	LabelSuccess = "SUCCESS"
+	LabelWarn    = "WARN"
+	LabelError   = "ERROR"
+)
+
+// Entry 一条日志记录
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Label   string // TRACE / DEBUG / INFO / SUCCESS / WARN / ERROR
+	Message string
+	Fields  []Field
+}
+
+// Formatter 把日志记录转换为一行输出（不含换行符）
+type Formatter interface {
+	Format(e *Entry) string
+}
+
+// NewFormatter 根据格式名创建 Formatter，color 只对 text 格式生效
+func NewFormatter(format string, color bool) (Formatter, error) {
+	switch format {
+	case "", FormatText:
+		return &TextFormatter{Color: color}, nil
+	case FormatJSON:
+		return &JSONFormatter{}, nil
+	case FormatLogfmt:
+		return &LogfmtFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected %s, %s or %s", format, FormatText, FormatJSON, FormatLogfmt)
+	}
+}
+
+// fieldsFromKV 把 key/value 交替的列表转换为字段，落单的 value 使用 "!BADKEY" 作为 key
+func fieldsFromKV(kv []interface{}) []Field {
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 >= len(kv) {
+			fields = append(fields, Field{Key: "!BADKEY", Value: kv[i]})
+			break
+		}
+		fields = append(fields, Field{Key: fmt.Sprint(kv[i]), Value: kv[i+1]})
+	}
+	return fields
+}
+
+// mergeFields 追加字段，同名字段以新值为准并保持原有位置
+func mergeFields(fields, extra []Field) []Field {
+	merged := append([]Field{}, fields...)
+	for _, f := range extra {
+		replaced := false
+		for i := range merged {
+			if merged[i].Key == f.Key {
+				merged[i].Value = f.Value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, f)
+		}
+	}
+	return merged
+}
+
+// fieldValue 把字段值转换为字符串，time.Duration 等类型使用 String()
+func fieldValue(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// TextFormatter 面向终端的格式：[时间] 级别 [组件] 消息 key=value
+type TextFormatter struct {
+	Color bool
+}
+
+func (f *TextFormatter) colorize(fn func(a ...interface{}) string, s string) string {
+	if !f.Color {
+		return s
+	}
+	return fn(s)
+}
+
+func (f *TextFormatter) Format(e *Entry) string {
+	var sb strings.Builder
+	for _, field := range e.Fields {
+		if field.Key == KeyComponent {
+			sb.WriteString("[" + strings.ToUpper(fieldValue(field.Value)) + "] ")
+		}
+	}
+	sb.WriteString(e.Message)
+	for _, field := range e.Fields {
+		if field.Key != KeyComponent {
+			sb.WriteString(" " + field.Key + "=" + logfmtValue(fieldValue(field.Value)))
+		}
+	}
+	// 调试信息不附加时间和级别，整行使用暗色
+	if e.Level <= LevelDebug {
+		return f.colorize(debugColor, sb.String())
+	}
+	timestamp := f.colorize(timeColor, "["+e.Time.Format("2006-01-02 15:04:05")+"]")
+	return fmt.Sprintf("%s %s %s", timestamp, f.colorize(labelColors[e.Label], e.Label), sb.String())
+}
+
+// JSONFormatter 每行一个 JSON 对象：time、level、msg 以及结构化字段
+type JSONFormatter struct{}
+
+func (f *JSONFormatter) Format(e *Entry) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONPair(&buf, "time", e.Time.Format(time.RFC3339))
+	buf.WriteByte(',')
+	writeJSONPair(&buf, "level", strings.ToLower(e.Label))
+	buf.WriteByte(',')
+	writeJSONPair(&buf, "msg", e.Message)
+	for _, field := range e.Fields {
+		buf.WriteByte(',')
+		writeJSONPair(&buf, field.Key, jsonValue(field.Value))
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// jsonValue 基本类型原样编码，其余类型转换为字符串
+func jsonValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil, bool, int, int64, float64, string:
+		return x
+	case time.Duration:
+		return x.String()
+	default:
+		return fieldValue(x)
+	}
+}
+
+func writeJSONPair(buf *bytes.Buffer, key string, value interface{}) {
+	k, _ := json.Marshal(key)
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(k)
+	buf.WriteByte(':')
+	buf.Write(v)
+}
+
+// LogfmtFormatter key=value 格式，便于 grep 和日志系统解析
+type LogfmtFormatter struct{}
+
+func (f *LogfmtFormatter) Format(e *Entry) string {
+	parts := []string{
+		"time=" + e.Time.Format(time.RFC3339),
+		"level=" + strings.ToLower(e.Label),
+		"msg=" + logfmtValue(e.Message),
+	}
+	for _, field := range e.Fields {
+		parts = append(parts, field.Key+"="+logfmtValue(fieldValue(field.Value)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// logfmtValue 含空格、引号、等号或为空的值加引号
+func logfmtValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\r\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
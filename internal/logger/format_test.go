@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTextFormatterRendersFields(t *testing.T) {
+	fields := []Field{
+		{Key: KeyComponent, Value: "plugin"},
+		{Key: "plugin", Value: "docker"},
+		{Key: "command", Value: "install"},
+		{Key: "duration", Value: 1500 * time.Millisecond},
+	}
+	f := &TextFormatter{}
+	for _, tt := range []struct {
+		level Level
+		label string
+		want  string
+	}{
+		{LevelDebug, LabelDebug, "[PLUGIN] Plugin execution finished plugin=docker command=install duration=1.5s"},
+		{LevelTrace, LabelTrace, "[PLUGIN] Plugin execution finished plugin=docker command=install duration=1.5s"},
+		{LevelInfo, LabelInfo, "[2026-01-02 03:04:05] INFO [PLUGIN] Plugin execution finished plugin=docker command=install duration=1.5s"},
+	} {
+		e := &Entry{
+			Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local),
+			Level:   tt.level,
+			Label:   tt.label,
+			Message: "Plugin execution finished",
+			Fields:  fields,
+		}
+		if got := f.Format(e); got != tt.want {
+			t.Errorf("Format(%s) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level 日志级别，数值越大越重要
+type Level int
+
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// EnvLogLevel 未通过 -v/-q 指定时读取的日志级别环境变量
+const EnvLogLevel = "TOOL_LOG_LEVEL"
+
+var levelNames = map[Level]string{
+	LevelTrace: "trace",
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel 解析 trace/debug/info/warn/error（不区分大小写，warning 视为 warn）
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		name = "warn"
+	}
+	for l, n := range levelNames {
+		if n == name {
+			return l, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q, expected trace, debug, info, warn or error", s)
+}
+
+// VerbosityLevel 根据 -v 次数和 -q 计算日志级别：-q 只输出错误，-v 为 debug，-vv 及以上为 trace
+func VerbosityLevel(verbose int, quiet bool) Level {
+	switch {
+	case quiet:
+		return LevelError
+	case verbose >= 2:
+		return LevelTrace
+	case verbose == 1:
+		return LevelDebug
+	default:
+		return LevelInfo
+	}
+}
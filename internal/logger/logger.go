@@ -2,13 +2,23 @@ package logger
 
 import "io"
 
+// Logger 分级日志接口，除 Print 外均为 printf 风格，末尾无需换行
+// 日志（诊断信息）和命令结果分别写入两个流，便于管道处理命令结果
 type Logger interface {
-	Info(msg string, args ...interface{})
-	Success(msg string, args ...interface{})
-	Warning(msg string, args ...interface{})
-	Error(msg string, args ...interface{})
-	Debug(args ...interface{})
+	Trace(format string, args ...interface{})
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Success(format string, args ...interface{}) // info 级别，标记为 SUCCESS
+	Warning(format string, args ...interface{})
+	Error(format string, args ...interface{})
+	// Print 原样输出到结果流（stdout），不受日志级别影响，用于表格、配置内容、插件输出等命令结果
 	Print(args ...interface{})
 
-	Writer() io.Writer // 新增：返回底层 io.Writer
+	// With 返回附带结构化字段的 Logger，kv 为 key/value 交替的列表，例如 With("plugin", "docker")
+	With(kv ...interface{}) Logger
+	// Enabled 判断某个级别的日志是否会输出
+	Enabled(level Level) bool
+
+	Writer() io.Writer    // 结果流，例如提供给 tablewriter
+	ErrWriter() io.Writer // 诊断流（stderr），日志写入这里
 }
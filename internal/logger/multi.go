@@ -0,0 +1,83 @@
+package logger
+
+import "io"
+
+// MultiLogger 把日志同时写入多个 Logger，例如终端文本 + 文件 JSON
+// Print、Writer 和 ErrWriter 只作用于第一个 Logger（通常是终端），避免表格等内容写入日志文件
+type MultiLogger struct {
+	loggers []Logger
+}
+
+// NewMultiLogger 创建 MultiLogger，只有一个 Logger 时直接返回它
+func NewMultiLogger(loggers ...Logger) Logger {
+	if len(loggers) == 1 {
+		return loggers[0]
+	}
+	return &MultiLogger{loggers: loggers}
+}
+
+func (m *MultiLogger) Trace(format string, args ...interface{}) {
+	for _, l := range m.loggers {
+		l.Trace(format, args...)
+	}
+}
+
+func (m *MultiLogger) Debug(format string, args ...interface{}) {
+	for _, l := range m.loggers {
+		l.Debug(format, args...)
+	}
+}
+
+func (m *MultiLogger) Info(format string, args ...interface{}) {
+	for _, l := range m.loggers {
+		l.Info(format, args...)
+	}
+}
+
+func (m *MultiLogger) Success(format string, args ...interface{}) {
+	for _, l := range m.loggers {
+		l.Success(format, args...)
+	}
+}
+
+func (m *MultiLogger) Warning(format string, args ...interface{}) {
+	for _, l := range m.loggers {
+		l.Warning(format, args...)
+	}
+}
+
+func (m *MultiLogger) Error(format string, args ...interface{}) {
+	for _, l := range m.loggers {
+		l.Error(format, args...)
+	}
+}
+
+func (m *MultiLogger) Print(args ...interface{}) {
+	m.loggers[0].Print(args...)
+}
+
+func (m *MultiLogger) With(kv ...interface{}) Logger {
+	children := make([]Logger, len(m.loggers))
+	for i, l := range m.loggers {
+		children[i] = l.With(kv...)
+	}
+	return &MultiLogger{loggers: children}
+}
+
+// Enabled 任一 Logger 会输出该级别时返回 true
+func (m *MultiLogger) Enabled(level Level) bool {
+	for _, l := range m.loggers {
+		if l.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MultiLogger) Writer() io.Writer {
+	return m.loggers[0].Writer()
+}
+
+func (m *MultiLogger) ErrWriter() io.Writer {
+	return m.loggers[0].ErrWriter()
+}
@@ -0,0 +1,101 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/utils"
+)
+
+// archAliases 常见的架构别名，统一为 Go 的 GOARCH 命名
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+	"i386":    "386",
+	"i686":    "386",
+	"armv7l":  "arm",
+}
+
+func normalizeArch(arch string) string {
+	arch = strings.ToLower(arch)
+	if a, ok := archAliases[arch]; ok {
+		return a
+	}
+	return arch
+}
+
+func matchAny(values []string, candidates ...string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		for _, c := range candidates {
+			if strings.EqualFold(v, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPlatform 判断主机是否满足单个平台声明
+func (h *Host) matchPlatform(p common.Platform) bool {
+	arches := make([]string, 0, len(p.Arch))
+	for _, a := range p.Arch {
+		arches = append(arches, normalizeArch(a))
+	}
+	distros := append([]string{h.Distro}, h.DistroLike...)
+	return matchAny(p.OS, h.OS) && matchAny(arches, h.Arch) && matchAny(p.Distro, distros...)
+}
+
+func (h *Host) describe() string {
+	desc := fmt.Sprintf("%s/%s", h.OS, h.Arch)
+	if h.Distro != "" {
+		desc += " " + h.Distro
+	}
+	return desc
+}
+
+// Check 检查插件的 platforms/requires 声明，返回不满足的原因列表，为空表示支持当前主机
+func Check(meta *common.Meta, h *Host) []string {
+	var reasons []string
+
+	if len(meta.Platforms) > 0 {
+		matched := false
+		for _, p := range meta.Platforms {
+			if h.matchPlatform(p) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			reasons = append(reasons, fmt.Sprintf("platform %s is not supported", h.describe()))
+		}
+	}
+
+	req := meta.Requires
+	if req == nil {
+		return reasons
+	}
+	for _, bin := range req.Binaries {
+		if _, err := h.LookPath(bin); err != nil {
+			reasons = append(reasons, fmt.Sprintf("required binary '%s' not found in PATH", bin))
+		}
+	}
+	if req.Kernel != "" {
+		c, err := utils.CompareVersions(h.Kernel, req.Kernel)
+		switch {
+		case h.Kernel == "":
+			reasons = append(reasons, fmt.Sprintf("kernel >= %s required, but kernel version is unknown", req.Kernel))
+		case err != nil:
+			reasons = append(reasons, fmt.Sprintf("cannot compare kernel version: %v", err))
+		case c < 0:
+			reasons = append(reasons, fmt.Sprintf("kernel >= %s required, current kernel is %s", req.Kernel, h.Kernel))
+		}
+	}
+	if req.Root && !h.Root {
+		reasons = append(reasons, "must be run as root")
+	}
+	return reasons
+}
@@ -0,0 +1,140 @@
+package platform
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bookandmusic/tool/internal/common"
+)
+
+func fakeHost(binaries ...string) *Host {
+	return &Host{
+		OS:         "linux",
+		Arch:       "amd64",
+		Distro:     "ubuntu",
+		DistroLike: []string{"debian"},
+		Kernel:     "6.8.0-45-generic",
+		LookPath: func(file string) (string, error) {
+			for _, b := range binaries {
+				if b == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+	}
+}
+
+func TestMatchPlatform(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform common.Platform
+		want     bool
+	}{
+		{"empty matches any host", common.Platform{}, true},
+		{"os", common.Platform{OS: []string{"linux"}}, true},
+		{"os case insensitive", common.Platform{OS: []string{"Linux"}}, true},
+		{"other os", common.Platform{OS: []string{"darwin"}}, false},
+		{"arch", common.Platform{Arch: []string{"amd64"}}, true},
+		{"arch alias", common.Platform{Arch: []string{"x86_64"}}, true},
+		{"other arch", common.Platform{Arch: []string{"aarch64"}}, false},
+		{"distro id", common.Platform{Distro: []string{"ubuntu"}}, true},
+		{"distro id_like", common.Platform{Distro: []string{"debian"}}, true},
+		{"other distro", common.Platform{Distro: []string{"fedora", "alpine"}}, false},
+		{"all fields", common.Platform{OS: []string{"linux"}, Arch: []string{"arm64", "amd64"}, Distro: []string{"debian"}}, true},
+		{"one field mismatch", common.Platform{OS: []string{"linux"}, Arch: []string{"arm64"}}, false},
+	}
+	h := fakeHost()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.matchPlatform(tt.platform); got != tt.want {
+				t.Errorf("matchPlatform(%+v) = %v, want %v", tt.platform, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		meta common.Meta
+		root bool
+		want []string // 原因中应包含的片段，为空表示支持
+	}{
+		{name: "no constraints", meta: common.Meta{}},
+		{
+			name: "any platform matches",
+			meta: common.Meta{Platforms: []common.Platform{{OS: []string{"darwin"}}, {Distro: []string{"debian"}}}},
+		},
+		{
+			name: "no platform matches",
+			meta: common.Meta{Platforms: []common.Platform{{OS: []string{"darwin"}}}},
+			want: []string{"platform linux/amd64 ubuntu is not supported"},
+		},
+		{
+			name: "binaries found",
+			meta: common.Meta{Requires: &common.Requires{Binaries: []string{"git"}}},
+		},
+		{
+			name: "binary missing",
+			meta: common.Meta{Requires: &common.Requires{Binaries: []string{"git", "docker"}}},
+			want: []string{"'docker' not found"},
+		},
+		{
+			name: "kernel new enough",
+			meta: common.Meta{Requires: &common.Requires{Kernel: "5.4"}},
+		},
+		{
+			name: "kernel equal",
+			meta: common.Meta{Requires: &common.Requires{Kernel: "6.8"}},
+		},
+		{
+			name: "kernel too old",
+			meta: common.Meta{Requires: &common.Requires{Kernel: "6.10"}},
+			want: []string{"kernel >= 6.10 required, current kernel is 6.8.0-45-generic"},
+		},
+		{
+			name: "root required",
+			meta: common.Meta{Requires: &common.Requires{Root: true}},
+			want: []string{"must be run as root"},
+		},
+		{
+			name: "root satisfied",
+			meta: common.Meta{Requires: &common.Requires{Root: true}},
+			root: true,
+		},
+		{
+			name: "all reasons reported",
+			meta: common.Meta{
+				Platforms: []common.Platform{{Arch: []string{"arm64"}}},
+				Requires:  &common.Requires{Binaries: []string{"docker"}, Root: true},
+			},
+			want: []string{"not supported", "'docker' not found", "root"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := fakeHost("git")
+			h.Root = tt.root
+			got := Check(&tt.meta, h)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Check() = %q, want %d reason(s)", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("reason %d = %q, want it to contain %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckUnknownKernel(t *testing.T) {
+	h := fakeHost()
+	h.Kernel = ""
+	got := Check(&common.Meta{Requires: &common.Requires{Kernel: "5.4"}}, h)
+	if len(got) != 1 || !strings.Contains(got[0], "kernel version is unknown") {
+		t.Errorf("Check() = %q, want unknown kernel reason", got)
+	}
+}
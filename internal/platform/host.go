@@ -0,0 +1,71 @@
+package platform
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Host 当前主机的平台信息
+type Host struct {
+	OS         string
+	Arch       string
+	Distro     string   // /etc/os-release 中的 ID
+	DistroLike []string // /etc/os-release 中的 ID_LIKE
+	Kernel     string   // 内核版本，例如 6.8.0-45-generic
+	Root       bool     // 是否以 root 身份运行
+
+	// LookPath 查找可执行文件，默认使用 exec.LookPath
+	LookPath func(file string) (string, error)
+}
+
+// Detect 探测主机平台信息
+// root 为文件系统根目录，/etc/os-release 和 /proc/sys/kernel/osrelease 均相对于它读取，
+// 为空时使用 "/"，便于在测试中伪造发行版和内核信息
+func Detect(root string) *Host {
+	if root == "" {
+		root = "/"
+	}
+	h := &Host{
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Root:     os.Geteuid() == 0,
+		LookPath: exec.LookPath,
+	}
+
+	release := readOSRelease(filepath.Join(root, "etc", "os-release"))
+	h.Distro = release["ID"]
+	h.DistroLike = strings.Fields(release["ID_LIKE"])
+
+	if data, err := os.ReadFile(filepath.Join(root, "proc", "sys", "kernel", "osrelease")); err == nil { // #nosec G304
+		h.Kernel = strings.TrimSpace(string(data))
+	}
+	return h
+}
+
+// readOSRelease 解析 os-release 格式的 KEY=VALUE 文件，文件不存在时返回空 map
+func readOSRelease(path string) map[string]string {
+	values := map[string]string{}
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return values
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[key] = strings.Trim(value, `"'`)
+	}
+	return values
+}
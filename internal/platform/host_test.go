@@ -0,0 +1,54 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "etc", "os-release"), `# comment
+NAME="Rocky Linux"
+ID="rocky"
+ID_LIKE="rhel centos fedora"
+
+VERSION_ID='9.4'
+`)
+	writeFile(t, filepath.Join(root, "proc", "sys", "kernel", "osrelease"), "5.14.0-427.el9.x86_64\n")
+
+	h := Detect(root)
+	if h.Distro != "rocky" {
+		t.Errorf("Distro = %q, want rocky", h.Distro)
+	}
+	if want := []string{"rhel", "centos", "fedora"}; !reflect.DeepEqual(h.DistroLike, want) {
+		t.Errorf("DistroLike = %q, want %q", h.DistroLike, want)
+	}
+	if h.Kernel != "5.14.0-427.el9.x86_64" {
+		t.Errorf("Kernel = %q", h.Kernel)
+	}
+	if h.LookPath == nil {
+		t.Error("LookPath is nil")
+	}
+}
+
+func TestDetectMissingFiles(t *testing.T) {
+	h := Detect(t.TempDir())
+	if h.Distro != "" || len(h.DistroLike) != 0 || h.Kernel != "" {
+		t.Errorf("Detect() on empty root = %+v, want no distro and kernel", h)
+	}
+	if h.OS == "" || h.Arch == "" {
+		t.Errorf("Detect() = %+v, want OS and Arch from runtime", h)
+	}
+}
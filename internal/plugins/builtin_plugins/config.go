@@ -0,0 +1,53 @@
+package builtinplugins
+
+import (
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/plugins"
+	"github.com/bookandmusic/tool/internal/service"
+)
+
+func configMeta(reg *plugins.Registry, rt *common.Runtime) *common.Meta {
+	return &common.Meta{
+		Name:    "config",
+		Desc:    "Inspect and edit the configuration",
+		Type:    common.Command,
+		BuiltIn: true,
+		Commands: []common.CommandDef{
+			{
+				Name: "get [key]",
+				Desc: "Print an effective config value, e.g. plugins.docker.install.docker-version",
+			},
+			{
+				Name: "set [key] [value]",
+				Desc: "Set a value in the user config, typed by the plugin flag or config schema",
+			},
+			{
+				Name: "unset [key]",
+				Desc: "Remove a value from the user config",
+			},
+			{
+				Name: "edit",
+				Desc: "Open the user config in $EDITOR and validate it on save",
+			},
+			{
+				Name: "validate",
+				Desc: "Validate all config layers and the plugins they reference",
+			},
+			{
+				Name: "migrate [cfg-path]",
+				Desc: "Upgrade a config file to the current version, default: the user config",
+				Flags: []*common.CommandFlag{
+					{Name: "dry-run", Desc: "Preview the changes without writing", Default: false},
+				},
+			},
+			{
+				Name: "show",
+				Desc: "Print the merged configuration of all layers",
+				Flags: []*common.CommandFlag{
+					{Name: "origin", Desc: "Show the file each value comes from", Default: false},
+				},
+			},
+		},
+		Service: &service.ToolConfigService{Runtime: rt, Registry: reg},
+	}
+}
@@ -0,0 +1,55 @@
+package builtinplugins
+
+import (
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/plugins"
+	"github.com/bookandmusic/tool/internal/service"
+)
+
+func pluginManageMeta(reg *plugins.Registry, rt *common.Runtime) *common.Meta {
+	return &common.Meta{
+		Name:    "plugin",
+		Desc:    "Inspect and manage plugins of all types",
+		Type:    common.Command,
+		BuiltIn: true,
+		Commands: []common.CommandDef{
+			{
+				Name: "ls [pattern]",
+				Desc: "List all plugins and load failures, optionally filtered by a name glob",
+				Flags: []*common.CommandFlag{
+					{Name: "type", Desc: "Only show plugins of this type: command or soft", Default: ""},
+					{Name: "enabled", Desc: "Only show enabled (--enabled) or disabled (--enabled=false) soft plugins", Default: false},
+					{Name: "builtin", Desc: "Only show builtin (--builtin) or extra (--builtin=false) plugins", Default: false},
+				},
+			},
+			{
+				Name: "info [name]",
+				Desc: "Show a plugin's metadata, flags with configured and effective values, and the built argv",
+				Flags: []*common.CommandFlag{
+					{Name: "resolve", Desc: "Resolve ${env|config|file|cmd:...} expressions in configured values (runs ${cmd:...})", Default: false},
+				},
+			},
+			{
+				Name: "search [term]",
+				Desc: "Search plugins by name and description",
+			},
+			{
+				Name: "new [name]",
+				Desc: "Generate a new plugin skeleton under the first plugin dir",
+				Flags: []*common.CommandFlag{
+					{Name: "type", Desc: "Plugin type: soft or command", Default: "command"},
+					{Name: "lang", Desc: "Script language: shell or python", Default: "shell"},
+				},
+			},
+			{
+				Name: "lint [dir...]",
+				Desc: "Validate plugin meta.yml files, default: all plugin dirs",
+			},
+			{
+				Name: "cache [clear]",
+				Desc: "Show the plugin metadata cache file, or remove it with 'clear'",
+			},
+		},
+		Service: &service.ToolPluginService{Runtime: rt, Registry: reg},
+	}
+}
@@ -6,36 +6,47 @@ import (
 	"github.com/bookandmusic/tool/internal/service"
 )
 
-var pluginMeta = &common.Meta{
-	Name:    "soft",
-	Desc:    "Manage soft (enable/disable/install/uninstall)",
-	Type:    common.Command,
-	BuiltIn: true,
-	Commands: []common.CommandDef{
-		{
-			Name: "enable [name]",
-			Desc: "Enable a soft plugin (writes install/uninstall flags into config)",
+func pluginMeta(reg *plugins.Registry, rt *common.Runtime) *common.Meta {
+	return &common.Meta{
+		Name:    "soft",
+		Desc:    "Manage soft (enable/disable/install/uninstall)",
+		Type:    common.Command,
+		BuiltIn: true,
+		Commands: []common.CommandDef{
+			{
+				Name: "enable [name]",
+				Desc: "Enable a soft plugin (writes install/uninstall flags into config)",
+			},
+			{
+				Name: "disable [name]",
+				Desc: "Disable a soft plugin (removes it from enabled list and config)",
+			},
+			{
+				Name: "ls",
+				Desc: "List soft plugins",
+			},
+			{
+				Name: "sync [name]",
+				Desc: "Merge new or changed meta.yml flag defaults into the config, keeping customized values",
+				Flags: []*common.CommandFlag{
+					{Name: "dry-run", Desc: "Show the changes without writing the config", Default: false},
+				},
+			},
+			{
+				Name: "install",
+				Desc: "install all enabled soft plugins",
+				Flags: []*common.CommandFlag{
+					{Name: "dry-run", Desc: "Print the commands without running them", Default: false},
+				},
+			},
+			{
+				Name: "destroy",
+				Desc: "uninstall all enabled soft plugins",
+				Flags: []*common.CommandFlag{
+					{Name: "dry-run", Desc: "Print the commands without running them", Default: false},
+				},
+			},
 		},
-		{
-			Name: "disable [name]",
-			Desc: "Disable a soft plugin (removes it from enabled list and config)",
-		},
-		{
-			Name: "ls",
-			Desc: "List soft plugins",
-		},
-		{
-			Name: "install",
-			Desc: "install all enabled soft plugins",
-		},
-		{
-			Name: "destroy",
-			Desc: "uninstall all enabled soft plugins",
-		},
-	},
-	Service: &service.PluginService{},
-}
-
-func init() {
-	plugins.RegisterMeta(pluginMeta)
+		Service: &service.PluginService{Runtime: rt, Registry: reg},
+	}
 }
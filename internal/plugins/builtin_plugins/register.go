@@ -0,0 +1,17 @@
+package builtinplugins
+
+import (
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/plugins"
+)
+
+// Register 把内置插件注册到 reg，管理类服务（soft、plugin、config）绑定到同一个注册表，
+// 所有服务共享 rt 中的配置、日志和环境变量
+func Register(reg *plugins.Registry, rt *common.Runtime) {
+	reg.MustRegister(configMeta(reg, rt))
+	reg.MustRegister(pluginManageMeta(reg, rt))
+	reg.MustRegister(pluginMeta(reg, rt))
+	reg.MustRegister(toolInitMeta(rt))
+	reg.MustRegister(toolVersionMeta(rt))
+	reg.MustRegister(toolSchemaMeta(rt))
+}
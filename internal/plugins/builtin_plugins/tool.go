@@ -2,24 +2,51 @@ package builtinplugins
 
 import (
 	"github.com/bookandmusic/tool/internal/common"
-	"github.com/bookandmusic/tool/internal/plugins"
 	"github.com/bookandmusic/tool/internal/service"
 )
 
-var toolInitMeta = &common.Meta{
-	Name:    "init",
-	Desc:    "tool init command",
-	Type:    common.Command,
-	BuiltIn: true,
-	Commands: []common.CommandDef{
-		{
-			Name: "config [cfg-path]",
-			Desc: "Generate default config file, default: ~/.config/tool.yml",
+func toolInitMeta(rt *common.Runtime) *common.Meta {
+	return &common.Meta{
+		Name:    "init",
+		Desc:    "tool init command",
+		Type:    common.Command,
+		BuiltIn: true,
+		Commands: []common.CommandDef{
+			{
+				Name: "config [cfg-path]",
+				Desc: "Generate default config file, default: ~/.config/tool.yml",
+			},
 		},
-	},
-	Service: &service.ToolInitService{},
+		Service: &service.ToolInitService{Runtime: rt},
+	}
 }
 
-func init() {
-	plugins.RegisterMeta(toolInitMeta)
+func toolVersionMeta(rt *common.Runtime) *common.Meta {
+	return &common.Meta{
+		Name:    "version",
+		Desc:    "Print the tool version",
+		Type:    common.Command,
+		BuiltIn: true,
+		Service: &service.ToolVersionService{Runtime: rt},
+	}
+}
+
+func toolSchemaMeta(rt *common.Runtime) *common.Meta {
+	return &common.Meta{
+		Name:    "schema",
+		Desc:    "Print JSON Schema of meta.yml or tool.yml",
+		Type:    common.Command,
+		BuiltIn: true,
+		Commands: []common.CommandDef{
+			{
+				Name: "meta",
+				Desc: "JSON Schema of plugin meta.yml",
+			},
+			{
+				Name: "config",
+				Desc: "JSON Schema of tool.yml",
+			},
+		},
+		Service: &service.ToolSchemaService{Runtime: rt},
+	}
 }
@@ -0,0 +1,130 @@
+package extraplugins
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bookandmusic/tool/internal/common"
+)
+
+// cacheVersion 缓存文件格式版本，格式变化时旧缓存整体失效
+const cacheVersion = 2
+
+// MetaCache 插件元数据缓存，避免每次启动都校验和解析所有 meta.yml。
+// 以插件目录为键，清单文件及其修改时间和大小未变化时直接使用缓存的结果（包括解析失败）。
+// 缓存只保存插件摘要（不含 flags 和子命令），命中时返回延迟加载的插件，见 common.Meta.Load
+type MetaCache struct {
+	Version     int                     `yaml:"version"`
+	ToolVersion string                  `yaml:"tool_version"` // 工具升级后 Schema 可能变化，缓存整体失效
+	Plugins     map[string]*cachedEntry `yaml:"plugins"`
+
+	path  string
+	dirty bool
+	seen  map[string]bool // 本次加载访问过的插件目录
+}
+
+// cachedEntry 单个插件目录的缓存
+type cachedEntry struct {
+	File    string       `yaml:"file"`  // 清单文件名，例如 meta.yml
+	ModTime int64        `yaml:"mtime"` // 清单修改时间（纳秒）
+	Size    int64        `yaml:"size"`
+	Meta    *common.Meta `yaml:"meta,omitempty"`  // 插件摘要，不含 flags 和子命令
+	Err     string       `yaml:"error,omitempty"` // 解析失败的原因
+}
+
+// OpenCache 读取 path 处的缓存，文件不存在、损坏或版本不匹配时返回空缓存
+func OpenCache(path string) *MetaCache {
+	c := &MetaCache{path: path, seen: map[string]bool{}}
+	if data, err := os.ReadFile(filepath.Clean(path)); err == nil {
+		_ = yaml.Unmarshal(data, c)
+	}
+	if c.Version != cacheVersion || c.ToolVersion != common.ToolVersion() || c.Plugins == nil {
+		c.Version = cacheVersion
+		c.ToolVersion = common.ToolVersion()
+		c.Plugins = map[string]*cachedEntry{}
+		c.dirty = true
+	}
+	return c
+}
+
+// load 返回 dir 中的插件元数据，缓存命中时不读取清单，返回的插件在 Load 时才解析清单
+func (c *MetaCache) load(dir string) (*common.Meta, error) {
+	metaPath := common.FindMetaFile(dir)
+	info, err := os.Stat(metaPath)
+	if metaPath == "" || err != nil {
+		// 清单不存在等情况交给 LoadMeta 报告，不缓存
+		return LoadMeta(dir)
+	}
+	file := filepath.Base(metaPath)
+	// 相对路径的插件目录在不同工作目录下指向不同位置，缓存使用绝对路径
+	key, err := filepath.Abs(dir)
+	if err != nil {
+		return LoadMeta(dir)
+	}
+	c.seen[key] = true
+	if e, ok := c.Plugins[key]; ok && e.File == file && e.ModTime == info.ModTime().UnixNano() && e.Size == info.Size() {
+		if e.Err != "" {
+			return nil, errors.New(e.Err)
+		}
+		if e.Meta != nil {
+			m := *e.Meta
+			bindMeta(&m, dir)
+			m.SetLoader(func() (*common.Meta, error) { return LoadMeta(dir) })
+			return &m, nil
+		}
+	}
+
+	meta, err := LoadMeta(dir)
+	e := &cachedEntry{File: file, ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+	if err != nil {
+		e.Err = err.Error()
+	} else {
+		m := *meta
+		m.Service, m.Flags, m.Commands = nil, nil, nil
+		e.Meta = &m
+	}
+	c.Plugins[key] = e
+	c.dirty = true
+	return meta, err
+}
+
+// prune 删除已不存在的插件目录。本次未访问的目录可能属于其他项目的 plugin_dirs，仍然保留
+func (c *MetaCache) prune() {
+	for dir := range c.Plugins {
+		if c.seen[dir] {
+			continue
+		}
+		if common.FindMetaFile(dir) == "" {
+			delete(c.Plugins, dir)
+			c.dirty = true
+		}
+	}
+}
+
+// Save 缓存有变化时写回文件
+func (c *MetaCache) Save() error {
+	if !c.dirty {
+		return nil
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o750); err != nil {
+		return err
+	}
+	if err := common.WriteFileAtomic(c.path, data, 0o600); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// bindMeta 设置插件目录，meta.yml 解析和读取缓存后都需要调用；执行服务在注册前由 bindService 绑定
+func bindMeta(m *common.Meta, dir string) {
+	m.Dir = dir
+	m.BuiltIn = false
+}
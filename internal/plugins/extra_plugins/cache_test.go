@@ -0,0 +1,83 @@
+package extraplugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const cachedManifest = `name: hello
+desc: Say hello
+type: command
+exec: run.sh
+flags:
+  - name: who
+    default: world
+`
+
+func TestMetaCacheLazyLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hello")
+	writeFile(t, filepath.Join(dir, "meta.yml"), cachedManifest)
+	cachePath := filepath.Join(t.TempDir(), "plugins.yml")
+
+	// 未命中：完整解析并写入摘要
+	cache := OpenCache(cachePath)
+	meta, err := cache.load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Lazy() || len(meta.Flags) != 1 {
+		t.Fatalf("cache miss meta = %+v, want fully loaded", meta)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 命中：只有摘要，flags 在 Load 时读取
+	cache = OpenCache(cachePath)
+	meta, err = cache.load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meta.Lazy() || meta.Name != "hello" || meta.Desc != "Say hello" || len(meta.Flags) != 0 {
+		t.Fatalf("cache hit meta = %+v, want lazy summary", meta)
+	}
+	if meta.Dir != dir {
+		t.Errorf("cache hit meta not bound to %s: %+v", dir, meta)
+	}
+	if err := meta.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Lazy() || len(meta.Flags) != 1 || meta.Flags[0].Name != "who" {
+		t.Errorf("loaded meta flags = %+v", meta.Flags)
+	}
+}
+
+func TestMetaCacheInvalidatedByChange(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hello")
+	metaPath := filepath.Join(dir, "meta.yml")
+	writeFile(t, metaPath, cachedManifest)
+	cachePath := filepath.Join(t.TempDir(), "plugins.yml")
+
+	cache := OpenCache(cachePath)
+	if _, err := cache.load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, metaPath, cachedManifest+"  - name: loud\n    default: false\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(metaPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := OpenCache(cachePath).load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Lazy() || len(meta.Flags) != 2 {
+		t.Errorf("changed manifest meta = %+v, want reparsed with 2 flags", meta)
+	}
+}
@@ -4,70 +4,192 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+	"github.com/bookandmusic/tool/internal/platform"
 	"github.com/bookandmusic/tool/internal/plugins"
 	"github.com/bookandmusic/tool/internal/service"
+	"github.com/bookandmusic/tool/internal/utils"
 )
 
-// LoadMeta 解析插件 meta.yml
+// LoadMeta 解析插件清单，支持 meta.yml、meta.yaml、meta.json 和 meta.toml
 func LoadMeta(dir string) (*common.Meta, error) {
-	metaPath := filepath.Join(dir, "meta.yml")
-	metaPath = filepath.Clean(metaPath)
-	data, err := os.ReadFile(metaPath)
+	metaPath := common.FindMetaFile(dir)
+	if metaPath == "" {
+		return nil, fmt.Errorf("no plugin manifest (%s) found in %s", strings.Join(common.MetaFileNames, ", "), dir)
+	}
+	data, err := common.ReadMetaFile(metaPath)
 	if err != nil {
 		return nil, err
 	}
+	if err := common.MetaSchema().ValidateYAML(data); err != nil {
+		return nil, err
+	}
 	var m common.Meta
 	if err := yaml.Unmarshal(data, &m); err != nil {
 		return nil, err
 	}
-	m.Dir = dir
-	m.BuiltIn = false
-	m.Service = &service.ExtraService{ExecDir: m.Dir, Exec: m.Exec, PluginName: m.Name, ExecType: m.ExecType}
+	bindMeta(&m, dir)
 	return &m, nil
 }
 
-func LoadAllExtraPluginMeta(cfg *common.Config) error {
-	console := common.GlobalCfg.Logger
-	for _, baseDir := range cfg.PluginDirs {
+// bindService 为插件目录中加载的插件绑定执行服务
+func bindService(rt *common.Runtime, m *common.Meta) {
+	m.Service = &service.ExtraService{Runtime: rt, ExecDir: m.Dir, Exec: m.Exec, PluginName: m.Name, ExecType: m.ExecType}
+}
+
+// InlineMeta 把配置中的内联插件转换为 command 插件，脚本在声明它的配置文件所在目录执行
+func InlineMeta(rt *common.Runtime, p common.InlinePlugin) *common.Meta {
+	return &common.Meta{
+		Name:     p.Name,
+		Desc:     p.Desc,
+		Type:     common.Command,
+		ExecType: "shell",
+		Flags:    p.Flags,
+		Dir:      p.Dir,
+		Service:  &service.ExtraService{Runtime: rt, PluginName: p.Name, ExecDir: p.Dir, ExecType: "shell", Script: p.Run},
+	}
+}
+
+// checkCompatible 校验插件声明的 requires_tool 是否被当前工具版本满足
+// 开发构建（版本未知）不做限制
+func checkCompatible(meta *common.Meta) error {
+	if meta.RequiresTool == "" {
+		return nil
+	}
+	toolVersion := common.ToolVersion()
+	if toolVersion == common.DevVersion {
+		return nil
+	}
+	ok, err := utils.CheckVersionConstraint(toolVersion, meta.RequiresTool)
+	if err != nil {
+		return fmt.Errorf("invalid requires_tool %q: %w", meta.RequiresTool, err)
+	}
+	if !ok {
+		return fmt.Errorf("requires tool %s, current version is %s", meta.RequiresTool, toolVersion)
+	}
+	return nil
+}
+
+// pluginSource 返回插件来源，用于重名提示
+func pluginSource(meta *common.Meta) string {
+	if meta.BuiltIn {
+		return "builtin"
+	}
+	return meta.Dir
+}
+
+// registerMeta 注册插件并处理重名：先注册的插件（plugin_dirs 中靠前的目录）优先，
+// 内置插件只有在 allow_override 中声明后才能被覆盖；
+// soft 插件与内置 soft 命令的子命令（ls、install 等）重名时视为被 soft 覆盖；
+// 被覆盖的插件声明了 namespace 时仍以 namespace/name 注册
+func registerMeta(console logger.Logger, reg *plugins.Registry, meta *common.Meta, cfg *common.Config) {
+	existing := reg.Get(meta.Name)
+	reserved := existing == nil && meta.Type == common.Soft && reg.SoftSubcommands()[meta.Name]
+	if reserved {
+		existing = reg.Get(plugins.SoftCommandName)
+	}
+	switch {
+	case existing == nil:
+		_ = reg.Register(meta)
+		console.Debug("Loaded plugin: %s", meta.Name)
+		return
+	case !reserved && existing.BuiltIn && slices.Contains(cfg.AllowOverride, meta.Name):
+		reg.Unregister(existing.Name)
+		_ = reg.Register(meta)
+		reg.RegisterShadowed(plugins.Shadow{Meta: existing, By: meta})
+		console.Debug("Plugin '%s' from %s overrides the builtin plugin", meta.Name, meta.Dir)
+		return
+	}
+
+	if qualified := meta.QualifiedName(); qualified != meta.Name && reg.Get(qualified) == nil {
+		console.Debug("Plugin '%s' from %s is shadowed by %s, registered as '%s'", meta.Name, meta.Dir, pluginSource(existing), qualified)
+		meta.Name = qualified
+		if svc, ok := meta.Service.(*service.ExtraService); ok {
+			svc.PluginName = qualified
+		}
+		_ = reg.Register(meta)
+		return
+	}
+
+	reg.RegisterShadowed(plugins.Shadow{Meta: meta, By: existing})
+	if reserved {
+		console.Warning("Soft plugin '%s' in %s collides with the builtin 'soft %s' command and is ignored, set a namespace in meta.yml to use it", meta.Name, meta.Dir, meta.Name)
+		return
+	}
+	if existing.BuiltIn {
+		console.Warning("Plugin '%s' in %s collides with a builtin plugin and is ignored, add it to allow_override to replace the builtin", meta.Name, meta.Dir)
+		return
+	}
+	console.Warning("Plugin '%s' in %s is shadowed by %s, set a namespace in meta.yml to use both", meta.Name, meta.Dir, existing.Dir)
+}
+
+// LoadAllExtraPluginMeta 加载 rt.Cfg.PluginDirs 中的插件并注册到 reg，插件的执行服务使用 rt；
+// 目录中的通配符按配置顺序展开，每个目录向下查找 PluginDepth 层；
+// cache 不为空时优先使用缓存的元数据，并在加载后写回
+func LoadAllExtraPluginMeta(rt *common.Runtime, reg *plugins.Registry, cache *MetaCache) error {
+	cfg := rt.Cfg
+	console := rt.Logger.With(logger.KeyComponent, "plugin")
+	host := platform.Detect(rt.HostRoot)
+	load := LoadMeta
+	if cache != nil {
+		load = cache.load
+	}
+	// 配置文件中的内联插件优先于插件目录中的同名插件
+	for _, p := range cfg.InlinePlugins {
+		registerMeta(console, reg, InlineMeta(rt, p), cfg)
+	}
+	for _, baseDir := range common.ExpandPluginDirs(cfg.PluginDirs, rt.Env.HomeDir()) {
 		info, err := os.Stat(baseDir)
 		if err != nil {
 			if os.IsNotExist(err) {
-				console.Debug(fmt.Sprintf("[PLUGIN] Plugin directory does not exist: %s\n", baseDir))
+				console.Debug("Plugin directory does not exist: %s", baseDir)
 				continue // 插件目录不存在，跳过
 			}
-			console.Warning("[PLUGIN] Cannot stat %s: %v", baseDir, err)
+			console.Warning("Cannot stat %s: %v", baseDir, err)
 			continue
 		}
 		if !info.IsDir() {
-			console.Debug(fmt.Sprintf("[PLUGIN] Not a directory, skipping: %s\n", baseDir))
+			console.Debug("Not a directory, skipping: %s", baseDir)
 			continue // 不是目录，跳过
 		}
 
-		entries, err := os.ReadDir(baseDir)
+		dirs, err := common.FindPluginDirs(baseDir, cfg.PluginDepth)
 		if err != nil {
-			console.Warning("[PLUGIN] Cannot read directory %s: %v", baseDir, err)
+			console.Warning("Cannot read directory %s: %v", baseDir, err)
 			continue
 		}
 
-		for _, e := range entries {
-			if !e.IsDir() {
-				console.Debug(fmt.Sprintf("[PLUGIN] Skipping non-directory entry: %s\n", e.Name()))
+		for _, dir := range dirs {
+			meta, err := load(dir)
+			if err != nil {
+				console.Warning("Skipping %s due to load error: %v", dir, err)
+				reg.RegisterFailure(plugins.LoadFailure{Name: filepath.Base(dir), Dir: dir, Err: err})
 				continue
 			}
-			dir := filepath.Join(baseDir, e.Name())
-
-			meta, err := LoadMeta(dir)
-			if err != nil {
-				console.Warning("[PLUGIN] Skipping %s due to load error: %v", dir, err)
+			if err := checkCompatible(meta); err != nil {
+				console.Warning("Skipping incompatible plugin '%s': %v", meta.Name, err)
+				reg.RegisterFailure(plugins.LoadFailure{Name: meta.Name, Dir: dir, Err: err})
 				continue
 			}
+			if reasons := platform.Check(meta, host); len(reasons) > 0 {
+				meta.Unsupported = strings.Join(reasons, "; ")
+				console.Debug("Plugin '%s' unsupported on this host: %s", meta.Name, meta.Unsupported)
+			}
 
-			plugins.RegisterMeta(meta)
-			console.Debug(fmt.Sprintf("[PLUGIN] Loaded plugin: %s\n", meta.Name))
+			bindService(rt, meta)
+			registerMeta(console, reg, meta, cfg)
+		}
+	}
+	if cache != nil {
+		cache.prune()
+		if err := cache.Save(); err != nil {
+			console.Debug("Failed to save plugin cache: %v", err)
 		}
 	}
 	return nil
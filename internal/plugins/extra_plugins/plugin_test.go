@@ -0,0 +1,169 @@
+package extraplugins
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+	"github.com/bookandmusic/tool/internal/plugins"
+	builtinplugins "github.com/bookandmusic/tool/internal/plugins/builtin_plugins"
+)
+
+// newRuntime 创建测试使用的 Runtime，日志丢弃，hostRoot 下的 /etc/os-release 由调用方伪造
+func newRuntime(cfg *common.Config, hostRoot string) *common.Runtime {
+	return &common.Runtime{
+		Cfg:      cfg,
+		Logger:   logger.NewLogger(io.Discard, io.Discard, logger.LevelError, &logger.TextFormatter{}),
+		HostRoot: hostRoot,
+		Secrets:  common.NewSecrets(),
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadAllMarksUnsupportedPlatform(t *testing.T) {
+	hostRoot := t.TempDir()
+	writeFile(t, filepath.Join(hostRoot, "etc", "os-release"), "ID=alpine\n")
+
+	pluginDir := t.TempDir()
+	writeFile(t, filepath.Join(pluginDir, "debonly", "meta.yml"), `name: debonly
+type: command
+exec: run.sh
+platforms:
+  - distro: [debian, ubuntu]
+`)
+	writeFile(t, filepath.Join(pluginDir, "alpine", "meta.yml"), `name: alpine
+type: command
+exec: run.sh
+platforms:
+  - distro: [alpine]
+`)
+
+	reg := plugins.NewRegistry()
+	cfg := &common.Config{PluginDirs: []string{pluginDir}}
+	if err := LoadAllExtraPluginMeta(newRuntime(cfg, hostRoot), reg, nil); err != nil {
+		t.Fatal(err)
+	}
+	deb := reg.Get("debonly")
+	if deb == nil {
+		t.Fatal("debonly not registered")
+	}
+	if !strings.Contains(deb.Unsupported, "alpine") {
+		t.Errorf("debonly.Unsupported = %q, want platform reason", deb.Unsupported)
+	}
+	if m := reg.Get("alpine"); m == nil || m.Unsupported != "" {
+		t.Errorf("alpine = %+v, want supported plugin", m)
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	old := common.Version
+	t.Cleanup(func() { common.Version = old })
+
+	tests := []struct {
+		version  string
+		requires string
+		wantErr  string
+	}{
+		{"v0.3.0", "", ""},
+		{"v0.3.0", ">=0.3", ""},
+		{"v0.3.0", ">=0.4", "requires tool >=0.4, current version is v0.3.0"},
+		{"v0.3.0", ">=x", "invalid requires_tool"},
+		{common.DevVersion, ">=9.0", ""},
+	}
+	for _, tt := range tests {
+		common.Version = tt.version
+		err := checkCompatible(&common.Meta{RequiresTool: tt.requires})
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("checkCompatible(%s, %q) error: %v", tt.version, tt.requires, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("checkCompatible(%s, %q) = %v, want %q", tt.version, tt.requires, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRegisterMetaSoftSubcommandNames(t *testing.T) {
+	cfg := &common.Config{AllowOverride: []string{"ls"}}
+	rt := newRuntime(cfg, "")
+	reg := plugins.NewRegistry()
+	builtinplugins.Register(reg, rt)
+
+	plain := &common.Meta{Name: "ls", Type: common.Soft, Dir: "/p/ls"}
+	namespaced := &common.Meta{Name: "install", Namespace: "team", Type: common.Soft, Dir: "/p/install"}
+	command := &common.Meta{Name: "sync", Type: common.Command, Dir: "/p/sync"}
+	for _, m := range []*common.Meta{plain, namespaced, command} {
+		registerMeta(rt.Logger, reg, m, cfg)
+	}
+
+	if m := reg.Get("ls"); m != nil {
+		t.Errorf("soft plugin 'ls' registered: %+v", m)
+	}
+	if m := reg.Get("team/install"); m != namespaced {
+		t.Errorf("team/install = %+v, want the namespaced soft plugin", m)
+	}
+	if m := reg.Get("sync"); m != command {
+		t.Errorf("sync = %+v, want the command plugin (only soft plugins live under soft)", m)
+	}
+	if soft := reg.Get(plugins.SoftCommandName); soft == nil || !soft.BuiltIn {
+		t.Errorf("builtin soft replaced: %+v", soft)
+	}
+	shadowed := reg.Shadowed()
+	if len(shadowed) != 1 || shadowed[0].Meta != plain || shadowed[0].By.Name != plugins.SoftCommandName {
+		t.Errorf("Shadowed() = %+v, want ls shadowed by soft", shadowed)
+	}
+}
+
+func TestRegisterMetaCollisions(t *testing.T) {
+	cfg := &common.Config{AllowOverride: []string{"config"}}
+	rt := newRuntime(cfg, "")
+	reg := plugins.NewRegistry()
+	builtinplugins.Register(reg, rt)
+	builtinConfig := reg.Get("config")
+	builtinVersion := reg.Get("version")
+
+	first := &common.Meta{Name: "deploy", Type: common.Command, Dir: "/one/deploy"}
+	second := &common.Meta{Name: "deploy", Type: common.Command, Dir: "/two/deploy"}
+	namespaced := &common.Meta{Name: "deploy", Namespace: "team", Type: common.Command, Dir: "/three/deploy"}
+	override := &common.Meta{Name: "config", Type: common.Command, Dir: "/one/config"}
+	version := &common.Meta{Name: "version", Type: common.Command, Dir: "/one/version"}
+	for _, m := range []*common.Meta{first, second, namespaced, override, version} {
+		bindService(rt, m)
+		registerMeta(rt.Logger, reg, m, cfg)
+	}
+
+	if reg.Get("deploy") != first {
+		t.Error("deploy: the plugin from the first directory does not win")
+	}
+	if reg.Get("team/deploy") != namespaced || namespaced.Name != "team/deploy" {
+		t.Errorf("team/deploy = %+v, want the shadowed plugin registered by its namespace", reg.Get("team/deploy"))
+	}
+	if reg.Get("config") != override {
+		t.Error("config: allow_override did not replace the builtin")
+	}
+	if reg.Get("version") != builtinVersion {
+		t.Error("version: builtin replaced without allow_override")
+	}
+
+	want := []plugins.Shadow{
+		{Meta: second, By: first},
+		{Meta: builtinConfig, By: override},
+		{Meta: version, By: builtinVersion},
+	}
+	if got := reg.Shadowed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Shadowed() = %+v, want %+v", got, want)
+	}
+}
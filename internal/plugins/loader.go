@@ -6,32 +6,64 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
 	"github.com/bookandmusic/tool/internal/utils"
 )
 
-func LoadAll(root *cobra.Command) error {
-	console := common.GlobalCfg.Logger
+// LoadAll 把注册表中的插件构建为 root 的子命令，soft 插件挂在内置 soft 命令下
+func LoadAll(root *cobra.Command, reg *Registry, console logger.Logger) error {
+	console = console.With(logger.KeyComponent, "plugin")
 	var softCmd *cobra.Command
-	for _, meta := range ListManyByType(common.Command) {
-		cmd := BuildPluginCmd(meta)
-		if meta.Name == "soft" {
+	for _, meta := range reg.ListByType(common.Command) {
+		cmd := BuildPluginCmd(meta, console)
+		if meta.Name == SoftCommandName && meta.BuiltIn {
 			softCmd = cmd
 		}
 		root.AddCommand(cmd)
-		console.Debug(fmt.Sprintf("[PLUGIN] %s loaded (type: %s)\n", meta.Name, meta.Type))
+		console.Debug("%s loaded (type: %s)", meta.Name, meta.Type)
 	}
 	if softCmd == nil {
 		softCmd = root
 	}
-	for _, meta := range ListManyByType(common.Soft) {
-		cmd := BuildPluginCmd(meta)
+	for _, meta := range reg.ListByType(common.Soft) {
+		cmd := BuildPluginCmd(meta, console)
 		softCmd.AddCommand(cmd)
-		console.Debug(fmt.Sprintf("[PLUGIN] %s loaded (type: %s)\n", meta.Name, meta.Type))
+		console.Debug("%s loaded (type: %s)", meta.Name, meta.Type)
 	}
 	return nil
 }
 
-func addFlagsToCmd(cmd *cobra.Command, flags []*common.CommandFlag) error {
+// addDeclaredFlag 按 flag.Type 注册标志，未声明或类型未知时返回 false
+func addDeclaredFlag(cmd *cobra.Command, flag *common.CommandFlag, usage string) bool {
+	switch flag.Type {
+	case common.FlagString:
+		value := ""
+		if flag.Default != nil {
+			value = fmt.Sprintf("%v", flag.Default)
+		}
+		cmd.Flags().String(flag.Name, value, usage)
+	case common.FlagBool:
+		b, _ := flag.Default.(bool)
+		cmd.Flags().Bool(flag.Name, b, usage)
+	case common.FlagInt:
+		i, _ := flag.Default.(int)
+		cmd.Flags().Int(flag.Name, i, usage)
+	case common.FlagFloat:
+		var f float64
+		switch v := flag.Default.(type) {
+		case float64:
+			f = v
+		case int:
+			f = float64(v)
+		}
+		cmd.Flags().Float64(flag.Name, f, usage)
+	default:
+		return false
+	}
+	return true
+}
+
+func addFlagsToCmd(cmd *cobra.Command, flags []*common.CommandFlag, console logger.Logger) error {
 	for _, flag := range flags {
 		flagValue := flag.Default
 		flagName := flag.Name
@@ -39,7 +71,11 @@ func addFlagsToCmd(cmd *cobra.Command, flags []*common.CommandFlag) error {
 		if flagUsage == "" {
 			flagUsage = fmt.Sprintf("Flag for %s", flagName)
 		}
-		// 假设 flags 是 "key" -> "value" 的结构，可以根据需要添加不同类型的标志
+		// 显式声明了类型时按声明类型注册，否则根据默认值推断
+		if addDeclaredFlag(cmd, flag, flagUsage) {
+			console.Trace("Added %s flag '%s' (default: %v) to command '%s'", flag.Type, flagName, flagValue, cmd.Use)
+			continue
+		}
 		switch v := flagValue.(type) {
 		case string:
 			// 添加字符串类型的标志
@@ -54,14 +90,23 @@ func addFlagsToCmd(cmd *cobra.Command, flags []*common.CommandFlag) error {
 			// 如果是其他类型，可以根据需要继续扩展
 			cmd.Flags().String(flagName, fmt.Sprintf("%v", flagValue), flagUsage)
 		}
-		console := common.GlobalCfg.Logger
-		console.Debug(fmt.Sprintf("[PLUGIN] Added flag '%s' (default: %v) to command '%s'\n", flagName, flagValue, cmd.Use))
+		console.Trace("Added flag '%s' (default: %v) to command '%s'", flagName, flagValue, cmd.Use)
 	}
 	return nil
 }
 
-func BuildPluginCmd(meta *common.Meta) *cobra.Command {
-	console := common.GlobalCfg.Logger
+// checkSupported 插件在当前主机不受支持时返回错误
+func checkSupported(meta *common.Meta, console logger.Logger) error {
+	if meta.Unsupported == "" {
+		return nil
+	}
+	console.Error("Plugin '%s' is unsupported on this host: %s", meta.Name, meta.Unsupported)
+	return fmt.Errorf("plugin '%s' is unsupported on this host: %s", meta.Name, meta.Unsupported)
+}
+
+// BuildPluginCmd 把插件元数据构建为命令，console 用于加载和执行前检查时的日志
+func BuildPluginCmd(meta *common.Meta, console logger.Logger) *cobra.Command {
+	console = console.With(logger.KeyComponent, "plugin")
 	short := meta.Desc
 	if short == "" {
 		short = fmt.Sprintf("%s %s plugin", meta.Name, meta.Type)
@@ -71,15 +116,24 @@ func BuildPluginCmd(meta *common.Meta) *cobra.Command {
 	pluginCmd := &cobra.Command{
 		Use:   meta.Name,
 		Short: short,
+		// 当前主机不支持的插件不在帮助中显示
+		Hidden: meta.Unsupported != "",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkSupported(meta, console); err != nil {
+				return err
+			}
 			return meta.Service.Handler(cmd, &common.CmdParams{
 				Flags: utils.CmdFlagsToMap(meta.Flags),
 			}, args, nil)
 		},
 	}
+	// 声明了命名空间的插件也可以通过 namespace/name 调用
+	if qualified := meta.QualifiedName(); qualified != meta.Name {
+		pluginCmd.Aliases = []string{qualified}
+	}
 	if meta.Flags != nil {
-		if err := addFlagsToCmd(pluginCmd, meta.Flags); err != nil {
-			console.Warning("[PLUGIN] Error adding flags to '%s': %v", meta.Name, err)
+		if err := addFlagsToCmd(pluginCmd, meta.Flags, console); err != nil {
+			console.Warning("Error adding flags to '%s': %v", meta.Name, err)
 		}
 	}
 
@@ -94,7 +148,9 @@ func BuildPluginCmd(meta *common.Meta) *cobra.Command {
 			Use:   sub.Name,
 			Short: subShort,
 			RunE: func(cmd *cobra.Command, args []string) error {
-
+				if err := checkSupported(meta, console); err != nil {
+					return err
+				}
 				return meta.Service.Handler(cmd, &common.CmdParams{
 					Name:  c.Name,
 					Flags: utils.CmdFlagsToMap(c.Flags),
@@ -102,12 +158,12 @@ func BuildPluginCmd(meta *common.Meta) *cobra.Command {
 			},
 		}
 		if sub.Flags != nil {
-			if err := addFlagsToCmd(subCmd, c.Flags); err != nil {
-				console.Warning("[PLUGIN] Error adding flags to subcommand '%s': %v", c.Name, err)
+			if err := addFlagsToCmd(subCmd, c.Flags, console); err != nil {
+				console.Warning("Error adding flags to subcommand '%s': %v", c.Name, err)
 			}
 		}
 		pluginCmd.AddCommand(subCmd)
-		console.Debug(fmt.Sprintf("[PLUGIN] Subcommand '%s' added to '%s'\n", sub.Name, pluginCmd.Use))
+		console.Trace("Subcommand '%s' added to '%s'", sub.Name, pluginCmd.Use)
 	}
 	return pluginCmd
 }
@@ -1,43 +1,155 @@
 package plugins
 
-import "github.com/bookandmusic/tool/internal/common"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
-// 注册表，用来存储每个 PluginType 对应的 Meta 切片
-var Registry = map[common.PluginType][]*common.Meta{}
+	"github.com/bookandmusic/tool/internal/common"
+)
 
-// RegisterMeta 注册新的插件元数据到 Registry
-func RegisterMeta(meta *common.Meta) {
-	pluginType := meta.Type
-	Registry[pluginType] = append(Registry[pluginType], meta)
+// LoadFailure 加载失败或被跳过的插件，供 plugin ls 展示
+type LoadFailure struct {
+	Name string // meta.yml 解析失败时为目录名
+	Dir  string
+	Err  error
 }
 
-// ListAll 返回所有插件，不按类型分类
-func ListAll() []*common.Meta {
-	var allPlugins []*common.Meta
-	for _, plugins := range Registry {
-		allPlugins = append(allPlugins, plugins...)
+// Shadow 因重名未生效的插件，Meta 被 By 覆盖
+type Shadow struct {
+	Meta *common.Meta
+	By   *common.Meta
+}
+
+// Registry 插件注册表，按名称索引，可并发访问；
+// 列表按名称排序，保证帮助信息和 ls 输出稳定
+type Registry struct {
+	mu        sync.RWMutex
+	metas     map[string]*common.Meta
+	qualified map[string]*common.Meta // namespace/name -> 插件，只包含名称与带命名空间名称不同的插件
+	failures  []LoadFailure           // 按发现顺序排列
+	shadowed  []Shadow                // 按发现顺序排列
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{metas: map[string]*common.Meta{}, qualified: map[string]*common.Meta{}}
+}
+
+// Register 注册插件元数据，名称已存在时返回错误
+func (r *Registry) Register(meta *common.Meta) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metas[meta.Name]; ok {
+		return fmt.Errorf("plugin '%s' already registered", meta.Name)
+	}
+	r.metas[meta.Name] = meta
+	// 多个插件的带命名空间名称相同时，先注册的优先
+	if q := meta.QualifiedName(); q != meta.Name && r.qualified[q] == nil {
+		r.qualified[q] = meta
 	}
-	return allPlugins
+	return nil
 }
 
-// ListManyByType 根据 PluginType 返回多个插件
-func ListManyByType(pluginType common.PluginType) []*common.Meta {
-	return Registry[pluginType]
+// MustRegister 注册插件元数据，名称已存在时 panic，用于内置插件
+func (r *Registry) MustRegister(meta *common.Meta) {
+	if err := r.Register(meta); err != nil {
+		panic(err)
+	}
 }
 
-// GetMetaByName 根据插件名称获取 Meta
-// 如果插件不存在，返回 nil
-func GetMetaByName(name string) *common.Meta {
-	// 遍历 Registry 中的所有插件类型
-	for _, plugins := range Registry {
-		// 遍历当前类型的所有插件
-		for _, plugin := range plugins {
-			if plugin.Name == name {
-				// 找到匹配的插件，返回对应的 Meta
-				return plugin
-			}
+// Unregister 按名称移除插件，返回被移除的插件，不存在时返回 nil
+func (r *Registry) Unregister(name string) *common.Meta {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	meta := r.metas[name]
+	delete(r.metas, name)
+	if meta != nil {
+		if q := meta.QualifiedName(); r.qualified[q] == meta {
+			delete(r.qualified, q)
 		}
 	}
-	// 如果没有找到插件，返回 nil
-	return nil
+	return meta
+}
+
+// SoftCommandName 挂载 soft 插件的内置命令
+const SoftCommandName = "soft"
+
+// SoftSubcommands 返回内置 soft 命令自身的子命令名称（ls、install 等），
+// soft 插件挂在同一命令下，不能与它们重名；soft 被外部插件覆盖时返回 nil
+func (r *Registry) SoftSubcommands() map[string]bool {
+	meta := r.Get(SoftCommandName)
+	if meta == nil || !meta.BuiltIn {
+		return nil
+	}
+	names := map[string]bool{}
+	for _, c := range meta.Commands {
+		if fields := strings.Fields(c.Name); len(fields) > 0 {
+			names[fields[0]] = true
+		}
+	}
+	return names
+}
+
+// Get 根据插件名称获取 Meta，name 也可以是带命名空间的名称，例如 team/docker
+// 名称优先于命名空间匹配，插件不存在时返回 nil
+func (r *Registry) Get(name string) *common.Meta {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if meta := r.metas[name]; meta != nil {
+		return meta
+	}
+	return r.qualified[name]
+}
+
+// List 返回所有插件，按名称排序
+func (r *Registry) List() []*common.Meta {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	metas := make([]*common.Meta, 0, len(r.metas))
+	for _, meta := range r.metas {
+		metas = append(metas, meta)
+	}
+	sort.Slice(metas, func(i, j int) bool { return metas[i].Name < metas[j].Name })
+	return metas
+}
+
+// ListByType 返回指定类型的插件，按名称排序
+func (r *Registry) ListByType(pluginType common.PluginType) []*common.Meta {
+	var metas []*common.Meta
+	for _, meta := range r.List() {
+		if meta.Type == pluginType {
+			metas = append(metas, meta)
+		}
+	}
+	return metas
+}
+
+// RegisterFailure 记录加载失败的插件
+func (r *Registry) RegisterFailure(f LoadFailure) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, f)
+}
+
+// Failures 返回加载失败的插件
+func (r *Registry) Failures() []LoadFailure {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]LoadFailure(nil), r.failures...)
+}
+
+// RegisterShadowed 记录被覆盖的插件
+func (r *Registry) RegisterShadowed(s Shadow) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.shadowed = append(r.shadowed, s)
+}
+
+// Shadowed 返回因重名未生效的插件
+func (r *Registry) Shadowed() []Shadow {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Shadow(nil), r.shadowed...)
 }
@@ -0,0 +1,129 @@
+package plugins
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bookandmusic/tool/internal/common"
+)
+
+func names(metas []*common.Meta) []string {
+	out := []string{}
+	for _, m := range metas {
+		out = append(out, m.Name)
+	}
+	return out
+}
+
+func TestRegistryRegisterCollision(t *testing.T) {
+	reg := NewRegistry()
+	first := &common.Meta{Name: "docker", Type: common.Soft}
+	if err := reg.Register(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register(&common.Meta{Name: "docker", Type: common.Command}); err == nil {
+		t.Error("registering a duplicate name succeeded")
+	}
+	if reg.Get("docker") != first {
+		t.Error("duplicate registration replaced the first plugin")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustRegister did not panic on a duplicate name")
+		}
+	}()
+	reg.MustRegister(&common.Meta{Name: "docker"})
+}
+
+func TestRegistryNamespacedLookup(t *testing.T) {
+	reg := NewRegistry()
+	docker := &common.Meta{Name: "docker", Namespace: "team"}
+	qualified := &common.Meta{Name: "ops/k8s", Namespace: "ops"}
+	plain := &common.Meta{Name: "team/docker"} // 名称恰好与带命名空间名称相同
+	reg.MustRegister(docker)
+	reg.MustRegister(qualified)
+
+	if reg.Get("team/docker") != docker {
+		t.Error("Get(team/docker) did not resolve the namespace")
+	}
+	if reg.Get("ops/k8s") != qualified {
+		t.Error("Get(ops/k8s) did not find a plugin registered by its qualified name")
+	}
+	if reg.Get("k8s") != nil || reg.Get("other/docker") != nil {
+		t.Error("Get matched a name without its namespace")
+	}
+
+	// 名称优先于命名空间匹配
+	reg.MustRegister(plain)
+	if reg.Get("team/docker") != plain {
+		t.Error("Get(team/docker) = namespaced plugin, want the plugin with that name")
+	}
+
+	if reg.Unregister("docker") != docker {
+		t.Error("Unregister(docker) did not return the plugin")
+	}
+	reg.Unregister("team/docker")
+	if m := reg.Get("team/docker"); m != nil {
+		t.Errorf("Get(team/docker) = %+v after Unregister", m)
+	}
+	if reg.Unregister("missing") != nil {
+		t.Error("Unregister(missing) returned a plugin")
+	}
+}
+
+func TestRegistryListSorted(t *testing.T) {
+	reg := NewRegistry()
+	for _, m := range []*common.Meta{
+		{Name: "zsh", Type: common.Soft},
+		{Name: "apt", Type: common.Command},
+		{Name: "mysql", Type: common.Soft},
+		{Name: "git", Type: common.Command},
+	} {
+		reg.MustRegister(m)
+	}
+	if got, want := names(reg.List()), []string{"apt", "git", "mysql", "zsh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+	if got, want := names(reg.ListByType(common.Soft)), []string{"mysql", "zsh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListByType(soft) = %v, want %v", got, want)
+	}
+}
+
+func TestRegistryShadowedAndFailures(t *testing.T) {
+	reg := NewRegistry()
+	a := &common.Meta{Name: "a", Dir: "/one/a"}
+	b := &common.Meta{Name: "a", Dir: "/two/a"}
+	reg.MustRegister(a)
+	reg.RegisterShadowed(Shadow{Meta: b, By: a})
+	reg.RegisterFailure(LoadFailure{Name: "broken", Dir: "/one/broken", Err: errors.New("bad manifest")})
+
+	shadowed := reg.Shadowed()
+	if len(shadowed) != 1 || shadowed[0].Meta != b || shadowed[0].By != a {
+		t.Errorf("Shadowed() = %+v", shadowed)
+	}
+	// 返回副本，调用方修改不影响注册表
+	shadowed[0] = Shadow{}
+	if reg.Shadowed()[0].Meta != b {
+		t.Error("Shadowed() returned the internal slice")
+	}
+	if failures := reg.Failures(); len(failures) != 1 || failures[0].Name != "broken" {
+		t.Errorf("Failures() = %+v", failures)
+	}
+	if reg.Get("a") != a {
+		t.Error("shadowed plugin replaced the registered one")
+	}
+}
+
+func TestRegistrySoftSubcommands(t *testing.T) {
+	reg := NewRegistry()
+	if reg.SoftSubcommands() != nil {
+		t.Error("SoftSubcommands() without soft command != nil")
+	}
+	reg.MustRegister(&common.Meta{Name: SoftCommandName, BuiltIn: true, Commands: []common.CommandDef{
+		{Name: "ls"}, {Name: "enable [name]"},
+	}})
+	if got, want := reg.SoftSubcommands(), map[string]bool{"ls": true, "enable": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SoftSubcommands() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Options 生成插件骨架的参数
+type Options struct {
+	Name         string // 插件名称
+	Type         string // soft | command
+	Lang         string // shell | python
+	RequiresTool string // 写入 meta.yml 的 requires_tool，为空则不写
+}
+
+// templateData 渲染模板时使用的数据
+type templateData struct {
+	Options
+	Exec     string
+	ExecType string
+	TestCmd  string
+}
+
+// file 单个生成文件：模板路径 -> 目标文件名
+type file struct {
+	tmpl string
+	name string
+	mode os.FileMode
+}
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func (o *Options) validate() error {
+	if !namePattern.MatchString(o.Name) {
+		return fmt.Errorf("invalid plugin name %q: use lowercase letters, digits, '-' or '_'", o.Name)
+	}
+	if o.Type != "soft" && o.Type != "command" {
+		return fmt.Errorf("invalid plugin type %q: must be soft or command", o.Type)
+	}
+	if o.Lang != "shell" && o.Lang != "python" {
+		return fmt.Errorf("invalid plugin language %q: must be shell or python", o.Lang)
+	}
+	return nil
+}
+
+func (o *Options) files() (templateData, []file) {
+	data := templateData{Options: *o}
+	var files []file
+	switch o.Lang {
+	case "python":
+		data.Exec = o.Name + ".py"
+		data.ExecType = "python"
+		data.TestCmd = fmt.Sprintf("python3 test_%s.py", o.Name)
+		files = []file{
+			{"templates/python/main.py.tmpl", data.Exec, 0o755},
+			{"templates/python/test.py.tmpl", fmt.Sprintf("test_%s.py", o.Name), 0o755},
+		}
+	default:
+		data.Exec = o.Name + ".sh"
+		data.ExecType = "shell"
+		data.TestCmd = fmt.Sprintf("bash test_%s.sh", o.Name)
+		files = []file{
+			{"templates/shell/main.sh.tmpl", data.Exec, 0o755},
+			{"templates/shell/test.sh.tmpl", fmt.Sprintf("test_%s.sh", o.Name), 0o755},
+		}
+	}
+	files = append(files,
+		file{"templates/meta.yml.tmpl", "meta.yml", 0o644},
+		file{"templates/README.md.tmpl", "README.md", 0o644},
+	)
+	return data, files
+}
+
+// Generate 在 baseDir 下创建名为 opts.Name 的插件目录并写入骨架文件，返回插件目录
+// 目标目录已存在时返回错误，不覆盖任何文件
+func Generate(baseDir string, opts Options) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(baseDir, opts.Name)
+	if _, err := os.Stat(dir); err == nil {
+		return "", fmt.Errorf("directory already exists: %s", dir)
+	}
+
+	data, files := opts.files()
+	rendered := make(map[string][]byte, len(files))
+	for _, f := range files {
+		tmpl, err := template.ParseFS(templateFS, f.tmpl)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("render %s: %w", f.name, err)
+		}
+		rendered[f.name] = buf.Bytes()
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, rendered[f.name], f.mode); err != nil { // #nosec G306 -- 脚本需要可执行权限
+			return "", err
+		}
+	}
+	return dir, nil
+}
@@ -0,0 +1,103 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/lint"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, typ := range []string{"soft", "command"} {
+		for _, lang := range []string{"shell", "python"} {
+			t.Run(typ+"-"+lang, func(t *testing.T) {
+				base := t.TempDir()
+				dir, err := Generate(base, Options{Name: "demo", Type: typ, Lang: lang, RequiresTool: ">=0.3"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if dir != filepath.Join(base, "demo") {
+					t.Fatalf("dir = %s, want %s", dir, filepath.Join(base, "demo"))
+				}
+
+				data, err := os.ReadFile(filepath.Join(dir, "meta.yml"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := common.MetaSchema().ValidateYAML(data); err != nil {
+					t.Fatalf("generated meta.yml fails schema validation: %v\n%s", err, data)
+				}
+				var meta common.Meta
+				if err := yaml.Unmarshal(data, &meta); err != nil {
+					t.Fatal(err)
+				}
+				if meta.Name != "demo" || meta.Type.String() != typ || meta.ExecType != lang || meta.RequiresTool != ">=0.3" {
+					t.Errorf("meta = %+v", meta)
+				}
+
+				// 入口脚本和测试脚本可执行
+				ext := map[string]string{"shell": ".sh", "python": ".py"}[lang]
+				for _, name := range []string{meta.Exec, "test_demo" + ext} {
+					info, err := os.Stat(filepath.Join(dir, name))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if info.Mode().Perm()&0o111 == 0 {
+						t.Errorf("%s mode = %v, want executable", name, info.Mode())
+					}
+				}
+				if _, err := os.Stat(filepath.Join(dir, "README.md")); err != nil {
+					t.Error(err)
+				}
+
+				// 生成的插件不应产生 lint 错误
+				if diags := lint.Dir(dir, lint.Reserved{}); lint.CountErrors(diags) > 0 {
+					t.Errorf("lint errors: %v", diags)
+				}
+			})
+		}
+	}
+}
+
+func TestGenerateWithoutRequiresTool(t *testing.T) {
+	dir, err := Generate(t.TempDir(), Options{Name: "demo", Type: "command", Lang: "shell"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "meta.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "requires_tool") {
+		t.Errorf("meta.yml should not declare requires_tool:\n%s", data)
+	}
+	if err := common.MetaSchema().ValidateYAML(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateRejects(t *testing.T) {
+	base := t.TempDir()
+	if err := os.Mkdir(filepath.Join(base, "exists"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []Options{
+		{Name: "Bad Name", Type: "command", Lang: "shell"},
+		{Name: "demo", Type: "daemon", Lang: "shell"},
+		{Name: "demo", Type: "command", Lang: "ruby"},
+		{Name: "exists", Type: "command", Lang: "shell"},
+	} {
+		if _, err := Generate(base, opts); err == nil {
+			t.Errorf("Generate(%+v) expected error", opts)
+		}
+	}
+	// 失败时不留下任何文件
+	if _, err := os.Stat(filepath.Join(base, "demo")); !os.IsNotExist(err) {
+		t.Errorf("rejected options left a plugin directory behind: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Draft 生成的 JSON Schema 版本
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema JSON Schema 的子集，足以描述 meta.yml / tool.yml 的结构
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	PatternProperties    map[string]*Schema `json:"patternProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // false 或 *Schema
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// Provider 自定义类型可实现该接口提供自身的 Schema，例如以字符串枚举序列化的整型
+type Provider interface {
+	JSONSchema() *Schema
+}
+
+var providerType = reflect.TypeOf((*Provider)(nil)).Elem()
+
+// Generate 根据结构体的 yaml 标签生成 Schema
+// 支持的附加标签：
+//   - desc:"..."          字段说明
+//   - schema:"required"   必填字段
+//   - enum:"a|b"          字符串枚举
+func Generate(v any, id, title string) *Schema {
+	s := generate(reflect.TypeOf(v))
+	s.Schema = Draft
+	s.ID = id
+	s.Title = title
+	return s
+}
+
+func generate(t reflect.Type) *Schema {
+	if t.Implements(providerType) {
+		return reflect.Zero(t).Interface().(Provider).JSONSchema()
+	}
+	if reflect.PointerTo(t).Implements(providerType) {
+		return reflect.New(t).Interface().(Provider).JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return generate(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: generate(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: generate(t.Elem())}
+	case reflect.Struct:
+		return generateStruct(t)
+	default:
+		// interface{} 等任意类型不做约束
+		return &Schema{}
+	}
+}
+
+func generateStruct(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		prop := generate(f.Type)
+		if desc := f.Tag.Get("desc"); desc != "" {
+			// 复制一份，避免修改 Provider 返回的共享实例
+			cp := *prop
+			cp.Description = desc
+			prop = &cp
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			cp := *prop
+			cp.Enum = nil
+			for _, e := range strings.Split(enum, "|") {
+				cp.Enum = append(cp.Enum, e)
+			}
+			prop = &cp
+		}
+		s.Properties[name] = prop
+		if f.Tag.Get("schema") == "required" {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// JSON 返回缩进格式的 JSON 文本
+func (s *Schema) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// Property 返回对象中 key 对应的子 Schema，未声明的键回退到 additionalProperties
+func (s *Schema) Property(key string) *Schema {
+	if s == nil {
+		return nil
+	}
+	if p, ok := s.Properties[key]; ok {
+		return p
+	}
+	if extra, ok := s.AdditionalProperties.(*Schema); ok {
+		return extra
+	}
+	return nil
+}
+
+// AllowExtensions 允许所有结构体对象包含以 prefix 开头的任意扩展键
+func (s *Schema) AllowExtensions(prefix string) {
+	if s == nil {
+		return
+	}
+	if s.AdditionalProperties == false {
+		s.PatternProperties = map[string]*Schema{"^" + regexp.QuoteMeta(prefix): {}}
+	}
+	for _, p := range s.Properties {
+		p.AllowExtensions(prefix)
+	}
+	if extra, ok := s.AdditionalProperties.(*Schema); ok {
+		extra.AllowExtensions(prefix)
+	}
+	s.Items.AllowExtensions(prefix)
+}
@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type level int
+
+func (level) JSONSchema() *Schema {
+	return &Schema{Type: "string", Enum: []any{"low", "high"}}
+}
+
+type genItem struct {
+	Name string `yaml:"name" schema:"required" desc:"item name"`
+}
+
+type genConfig struct {
+	Name    string            `yaml:"name" schema:"required"`
+	Mode    string            `yaml:"mode,omitempty" enum:"fast|slow"`
+	Count   int               `yaml:"count"`
+	Ratio   float64           `yaml:"ratio"`
+	Enabled *bool             `yaml:"enabled"`
+	Level   level             `yaml:"level" desc:"log level"`
+	Items   []genItem         `yaml:"items"`
+	Labels  map[string]string `yaml:"labels"`
+	Any     any               `yaml:"any"`
+	Skipped string            `yaml:"-"`
+	Untag   string
+	hidden  string
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate(genConfig{}, "https://example.com/tool.json", "tool")
+	if s.Schema != Draft || s.ID != "https://example.com/tool.json" || s.Title != "tool" {
+		t.Errorf("header = %q %q %q", s.Schema, s.ID, s.Title)
+	}
+	if s.Type != "object" || s.AdditionalProperties != false {
+		t.Errorf("root = %s additionalProperties=%v, want a closed object", s.Type, s.AdditionalProperties)
+	}
+	if !reflect.DeepEqual(s.Required, []string{"name"}) {
+		t.Errorf("Required = %v, want [name]", s.Required)
+	}
+
+	wantTypes := map[string]string{
+		"name": "string", "mode": "string", "count": "integer", "ratio": "number",
+		"enabled": "boolean", "level": "string", "items": "array", "labels": "object",
+		"any": "", "untag": "string",
+	}
+	for name, typ := range wantTypes {
+		prop, ok := s.Properties[name]
+		if !ok {
+			t.Errorf("property %q missing", name)
+			continue
+		}
+		if prop.Type != typ {
+			t.Errorf("%s.type = %q, want %q", name, prop.Type, typ)
+		}
+	}
+	for _, name := range []string{"Skipped", "skipped", "hidden"} {
+		if _, ok := s.Properties[name]; ok {
+			t.Errorf("property %q generated", name)
+		}
+	}
+
+	if got := s.Properties["mode"].Enum; !reflect.DeepEqual(got, []any{"fast", "slow"}) {
+		t.Errorf("mode.enum = %v", got)
+	}
+	level := s.Properties["level"]
+	if level.Description != "log level" || !reflect.DeepEqual(level.Enum, []any{"low", "high"}) {
+		t.Errorf("level = %+v, want provider schema with description", level)
+	}
+	items := s.Properties["items"].Items
+	if items == nil || !reflect.DeepEqual(items.Required, []string{"name"}) || items.Properties["name"].Description != "item name" {
+		t.Errorf("items = %+v", items)
+	}
+	if extra, ok := s.Properties["labels"].AdditionalProperties.(*Schema); !ok || extra.Type != "string" {
+		t.Errorf("labels.additionalProperties = %v, want string schema", s.Properties["labels"].AdditionalProperties)
+	}
+}
+
+func TestAllowExtensions(t *testing.T) {
+	s := Generate(genConfig{}, "", "")
+	s.AllowExtensions("x-")
+	for name, sc := range map[string]*Schema{"root": s, "items": s.Properties["items"].Items} {
+		if _, ok := sc.PatternProperties["^x-"]; !ok {
+			t.Errorf("%s.patternProperties = %v, want ^x-", name, sc.PatternProperties)
+		}
+	}
+	// map 的值不是封闭对象，不需要扩展规则
+	if s.Properties["labels"].PatternProperties != nil {
+		t.Errorf("labels.patternProperties = %v", s.Properties["labels"].PatternProperties)
+	}
+}
+
+func TestSchemaJSON(t *testing.T) {
+	data, err := Generate(genItem{}, "", "item").JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["$schema"] != Draft || got["additionalProperties"] != false {
+		t.Errorf("json = %s", data)
+	}
+	if _, ok := got["$id"]; ok {
+		t.Errorf("empty $id serialized: %s", data)
+	}
+}
+
+func TestProperty(t *testing.T) {
+	s := Generate(genConfig{}, "", "")
+	if p := s.Property("count"); p == nil || p.Type != "integer" {
+		t.Errorf("Property(count) = %+v", p)
+	}
+	if p := s.Property("nope"); p != nil {
+		t.Errorf("Property(nope) = %+v, want nil for a closed object", p)
+	}
+	if p := s.Properties["labels"].Property("any-key"); p == nil || p.Type != "string" {
+		t.Errorf("labels.Property(any-key) = %+v, want additionalProperties", p)
+	}
+	var nilSchema *Schema
+	if nilSchema.Property("x") != nil {
+		t.Error("nil schema returned a property")
+	}
+}
@@ -0,0 +1,189 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ValidationError 单条校验错误，Line/Column 来自 YAML 节点
+type ValidationError struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "<root>"
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, path, e.Message)
+}
+
+// ValidationErrors 多条校验错误
+type ValidationErrors []ValidationError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ValidateYAML 解析 YAML 文本并按 Schema 校验，语法错误直接返回
+func (s *Schema) ValidateYAML(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if errs := s.ValidateNode(&doc); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateNode 按 Schema 校验 YAML 节点树，返回所有错误
+func (s *Schema) ValidateNode(node *yaml.Node) ValidationErrors {
+	var errs ValidationErrors
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil // 空文档
+		}
+		node = node.Content[0]
+	}
+	s.validate(node, "", &errs)
+	return errs
+}
+
+func joinPath(base, key string) string {
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
+
+func (s *Schema) fail(errs *ValidationErrors, node *yaml.Node, path, format string, args ...any) {
+	*errs = append(*errs, ValidationError{Path: path, Line: node.Line, Column: node.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *Schema) validate(node *yaml.Node, path string, errs *ValidationErrors) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.ShortTag() == "!!null" {
+		return // 空值等同于未设置
+	}
+	if s.Type != "" && !matchesType(s.Type, node) {
+		s.fail(errs, node, path, "expected %s, got %s", s.Type, describe(node))
+		return
+	}
+	if len(s.Enum) > 0 && !s.matchesEnum(node) {
+		s.fail(errs, node, path, "value %q is not one of %v", node.Value, s.Enum)
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		s.validateMapping(node, path, errs)
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for i, item := range node.Content {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+func (s *Schema) validateMapping(node *yaml.Node, path string, errs *ValidationErrors) {
+	present := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		present[key.Value] = true
+		keyPath := joinPath(path, key.Value)
+		if prop, ok := s.Properties[key.Value]; ok {
+			prop.validate(value, keyPath, errs)
+			continue
+		}
+		if prop := s.matchPattern(key.Value); prop != nil {
+			prop.validate(value, keyPath, errs)
+			continue
+		}
+		switch extra := s.AdditionalProperties.(type) {
+		case *Schema:
+			extra.validate(value, keyPath, errs)
+		case bool:
+			if !extra {
+				s.fail(errs, key, keyPath, "unknown field %q", key.Value)
+			}
+		}
+	}
+	required := append([]string(nil), s.Required...)
+	sort.Strings(required)
+	for _, name := range required {
+		if !present[name] {
+			s.fail(errs, node, path, "missing required field %q", name)
+		}
+	}
+}
+
+func matchesType(typ string, node *yaml.Node) bool {
+	switch typ {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	case "string":
+		// 与 yaml.v3 的解码保持一致：任意标量都能解码为字符串，例如 version: 1.0、requires_tool: 1
+		return node.Kind == yaml.ScalarNode
+	case "boolean":
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!bool"
+	case "integer":
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!int"
+	case "number":
+		return node.Kind == yaml.ScalarNode && (node.ShortTag() == "!!int" || node.ShortTag() == "!!float")
+	}
+	return true
+}
+
+func (s *Schema) matchesEnum(node *yaml.Node) bool {
+	for _, e := range s.Enum {
+		if fmt.Sprint(e) == node.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!str":
+		return "string"
+	}
+	return node.ShortTag()
+}
+
+func (s *Schema) matchPattern(key string) *Schema {
+	for pattern, prop := range s.PatternProperties {
+		if ok, _ := regexp.MatchString(pattern, key); ok {
+			return prop
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateYAML(t *testing.T) {
+	s := Generate(genConfig{}, "", "")
+	s.AllowExtensions("x-")
+
+	tests := []struct {
+		name string
+		yaml string
+		want []string // 每条错误需包含的内容，为空表示校验通过
+	}{
+		{name: "valid", yaml: "name: a\nmode: fast\ncount: 2\nratio: 1\nenabled: true\nitems:\n  - name: i\nlabels:\n  k: v\nany: [1, {a: b}]\n"},
+		{name: "empty document", yaml: ""},
+		{name: "null value is unset", yaml: "name: a\ncount: ~\n"},
+		{name: "scalars decode into strings", yaml: "name: 1.0\nmode: fast\nlabels:\n  k: 1\n  b: true\n"},
+		{name: "missing required", yaml: "count: 1\n", want: []string{`line 1: <root>: missing required field "name"`}},
+		{name: "missing required in item", yaml: "name: a\nitems:\n  - {}\n", want: []string{`items[0]: missing required field "name"`}},
+		{name: "unknown field", yaml: "name: a\nnope: 1\n", want: []string{`line 2: nope: unknown field "nope"`}},
+		{name: "unknown nested field", yaml: "name: a\nitems:\n  - name: i\n    extra: 1\n", want: []string{`items[0].extra: unknown field "extra"`}},
+		{name: "extension keys", yaml: "name: a\nx-note: anything\nitems:\n  - name: i\n    x-tag: [1]\n"},
+		{name: "integer mismatch", yaml: "name: a\ncount: many\n", want: []string{"count: expected integer, got string"}},
+		{name: "number accepts integer", yaml: "name: a\nratio: 3\n"},
+		{name: "boolean mismatch", yaml: "name: a\nenabled: yes please\n", want: []string{"enabled: expected boolean, got string"}},
+		{name: "string rejects object", yaml: "name: {a: b}\n", want: []string{"name: expected string, got object"}},
+		{name: "array mismatch", yaml: "name: a\nitems: x\n", want: []string{"items: expected array, got string"}},
+		{name: "object mismatch", yaml: "name: a\nlabels: [a]\n", want: []string{"labels: expected object, got array"}},
+		{name: "enum", yaml: "name: a\nmode: medium\n", want: []string{`mode: value "medium" is not one of [fast slow]`}},
+		{name: "map values", yaml: "name: a\nlabels:\n  k: [1]\n", want: []string{"labels.k: expected string, got array"}},
+		{name: "all errors", yaml: "count: x\nnope: 1\n", want: []string{"count: expected integer", `unknown field "nope"`, `missing required field "name"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.ValidateYAML([]byte(tt.yaml))
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateYAML() error: %v", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("ValidateYAML() = %v, want ValidationErrors", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("ValidateYAML() = %v, want %d errors", errs, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("error[%d] = %q, want %q", i, errs[i].Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateYAMLSyntaxError(t *testing.T) {
+	err := Generate(genConfig{}, "", "").ValidateYAML([]byte("name: [a\n"))
+	var errs ValidationErrors
+	if err == nil || errors.As(err, &errs) {
+		t.Errorf("ValidateYAML() = %v, want a YAML syntax error", err)
+	}
+}
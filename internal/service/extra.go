@@ -3,19 +3,25 @@ package service
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
 	"github.com/bookandmusic/tool/internal/utils"
 )
 
+// ExtraService 执行插件目录和内联插件的脚本，Runtime 为所属 App 的运行时状态
 type ExtraService struct {
+	Runtime    *common.Runtime
 	PluginName string
 	ExecDir    string
 	Exec       string
 	ExecType   string
+	Script     string // 内联插件的脚本，不为空时通过 executor.shell -c 执行，忽略 Exec
 }
 
 // 合并 flags/kwargs
@@ -39,14 +45,16 @@ func (e *ExtraService) buildExecPath() string {
 
 // 构建最终命令参数
 func (e *ExtraService) buildFinalArgs(execPath string, cmdParams *common.CmdParams, mergedArgs map[string]any, args []string) []string {
-	executor := common.GlobalCfg.Cfg.Executor
+	executor := e.Runtime.Cfg.Executor
 	var finalArgs []string
 
-	// 选择执行器
-	switch e.ExecType {
-	case "shell":
+	// 选择执行器，内联脚本的 $0 为插件名，flags 和参数依次为 $1...
+	switch {
+	case e.Script != "":
+		finalArgs = append(finalArgs, executor.Shell, "-c", e.Script, e.PluginName)
+	case e.ExecType == "shell":
 		finalArgs = append(finalArgs, executor.Shell, execPath)
-	case "python":
+	case e.ExecType == "python":
 		finalArgs = append(finalArgs, executor.Python, execPath)
 	default:
 		finalArgs = append(finalArgs, execPath)
@@ -57,9 +65,14 @@ func (e *ExtraService) buildFinalArgs(execPath string, cmdParams *common.CmdPara
 		finalArgs = append(finalArgs, cmdParams.Name)
 	}
 
-	// 参数转换
-	for key, value := range mergedArgs {
-		switch v := value.(type) {
+	// 参数转换，按名称排序保证每次生成的命令一致
+	keys := make([]string, 0, len(mergedArgs))
+	for key := range mergedArgs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch v := mergedArgs[key].(type) {
 		case bool:
 			if v {
 				finalArgs = append(finalArgs, fmt.Sprintf("--%s", key))
@@ -74,25 +87,47 @@ func (e *ExtraService) buildFinalArgs(execPath string, cmdParams *common.CmdPara
 	return finalArgs
 }
 
-func (e *ExtraService) Handler(cmd *cobra.Command, cmdParams *common.CmdParams, args []string, kwargs map[string]any) error {
-	console := common.GlobalCfg.Logger
-
+// BuildCommand 返回将要执行的完整命令参数，供 Handler 和 dry-run 共用
+func (e *ExtraService) BuildCommand(cmd *cobra.Command, cmdParams *common.CmdParams, args []string, kwargs map[string]any) ([]string, error) {
 	// 1. 获取参数
 	mergedArgs, err := e.getMergedArgs(cmd, cmdParams, kwargs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 2. 生成执行路径
 	execPath := e.buildExecPath()
 
 	// 3. 构建最终命令参数
-	finalArgs := e.buildFinalArgs(execPath, cmdParams, mergedArgs, args)
+	return e.buildFinalArgs(execPath, cmdParams, mergedArgs, args), nil
+}
+
+func (e *ExtraService) Handler(cmd *cobra.Command, cmdParams *common.CmdParams, args []string, kwargs map[string]any) error {
+	console := e.Runtime.Logger.With(logger.KeyComponent, "plugin", "plugin", e.PluginName)
+	if cmdParams != nil && cmdParams.Name != "" {
+		console = console.With("command", cmdParams.Name)
+	}
 
-	// 4. 执行命令
-	err = utils.RunCommand(console, false, nil, e.ExecDir, finalArgs...)
+	finalArgs, err := e.BuildCommand(cmd, cmdParams, args, kwargs)
 	if err != nil {
-		console.Error("[PLUGIN] Plugin '%s' execution failed: %v", e.PluginName, err)
+		return err
+	}
+
+	// 4. 执行命令，通过环境变量告知插件当前工具版本
+	env := map[string]string{"TOOL_VERSION": common.ToolVersion()}
+	start := time.Now()
+	err = utils.RunCommand(cmd.Context(), console, utils.RunOptions{
+		Stdin:   e.Runtime.Stdin,
+		Environ: e.Runtime.Env.List(),
+		Env:     env,
+		Dir:     e.ExecDir,
+		Secrets: e.Runtime.Secrets,
+	}, finalArgs...)
+	console = console.With("duration", time.Since(start).Round(time.Millisecond))
+	if err != nil {
+		console.Error("Plugin execution failed: %v", err)
+		return err
 	}
-	return err
+	console.Debug("Plugin execution finished")
+	return nil
 }
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+)
+
+// Column 列表中的一列
+type Column struct {
+	Key    string // JSON/YAML/CSV 字段名，也是 --columns 中使用的名称
+	Header string // 表格和 Markdown 的列标题
+	// Display 仅用于表格的显示转换（例如着色），为空时直接显示值
+	Display func(v any) string
+}
+
+// Listing 列表命令的输出，按 --output 渲染为 table/json/yaml/csv/markdown
+type Listing struct {
+	Columns []Column
+	// Default 未指定 --columns 时表格和 Markdown 显示的列，为空表示全部；
+	// json/yaml/csv 默认输出全部列，保证字段稳定
+	Default []string
+	Rows    [][]any // 与 Columns 一一对应
+	Caption string  // 表格下方的说明，仅 table 格式显示
+}
+
+// AppendRow 追加一行，values 与 Columns 顺序一致
+func (l *Listing) AppendRow(values ...any) {
+	l.Rows = append(l.Rows, values)
+}
+
+// selectColumns 按 --columns（names）或默认列返回要输出的列下标
+func (l *Listing) selectColumns(format string, names []string) ([]int, error) {
+	if len(names) == 0 && (format == common.OutputTable || format == common.OutputMarkdown) {
+		names = l.Default
+	}
+	if len(names) == 0 {
+		return l.allColumns(), nil
+	}
+
+	var idx []int
+	for _, name := range names {
+		found := false
+		for i, c := range l.Columns {
+			if c.Key == name {
+				idx = append(idx, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q, available: %s", name, strings.Join(l.columnKeys(), ", "))
+		}
+	}
+	return idx, nil
+}
+
+func (l *Listing) allColumns() []int {
+	idx := make([]int, len(l.Columns))
+	for i := range idx {
+		idx[i] = i
+	}
+	return idx
+}
+
+func (l *Listing) columnKeys() []string {
+	keys := make([]string, len(l.Columns))
+	for i, c := range l.Columns {
+		keys[i] = c.Key
+	}
+	return keys
+}
+
+// Render 按 rt 中的 --output/--columns 输出到 console，表格是否着色由 rt.Color 决定
+func (l *Listing) Render(rt *common.Runtime, console logger.Logger) error {
+	format := rt.Output
+	if format == "" {
+		format = common.OutputTable
+	}
+	idx, err := l.selectColumns(format, rt.Columns)
+	if err != nil {
+		console.Error("%v", err)
+		return err
+	}
+
+	switch format {
+	case common.OutputTable:
+		return l.renderTable(console, idx, false, rt.Color)
+	case common.OutputMarkdown:
+		return l.renderTable(console, idx, true, false)
+	case common.OutputJSON:
+		data, err := marshalJSON(l.records(idx), "  ")
+		if err != nil {
+			return err
+		}
+		console.Print(string(data))
+		return nil
+	case common.OutputYAML:
+		data, err := yaml.Marshal(l.yamlNode(idx))
+		if err != nil {
+			return err
+		}
+		console.Print(string(data))
+		return nil
+	case common.OutputCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		header := make([]string, 0, len(idx))
+		for _, i := range idx {
+			header = append(header, l.Columns[i].Key)
+		}
+		_ = w.Write(header)
+		for _, row := range l.Rows {
+			record := make([]string, 0, len(idx))
+			for _, i := range idx {
+				record = append(record, fmt.Sprint(row[i]))
+			}
+			_ = w.Write(record)
+		}
+		w.Flush()
+		console.Print(buf.String())
+		return w.Error()
+	default:
+		err := fmt.Errorf("unknown output format %q, expected %s", format, strings.Join(common.OutputFormats, ", "))
+		console.Error("%v", err)
+		return err
+	}
+}
+
+// renderTable 输出表格或 Markdown 表格，只包含 idx 指定的列，color 为 false 时不输出颜色
+func (l *Listing) renderTable(console logger.Logger, idx []int, markdown, color bool) error {
+	t := newTable(console)
+	header := table.Row{}
+	for _, i := range idx {
+		header = append(header, l.Columns[i].Header)
+	}
+	t.AppendHeader(header)
+	for _, row := range l.Rows {
+		r := table.Row{}
+		for _, i := range idx {
+			r = append(r, l.display(i, row[i], color && !markdown))
+		}
+		t.AppendRow(r)
+	}
+	if markdown {
+		t.RenderMarkdown()
+		return nil
+	}
+	if l.Caption != "" {
+		t.SetCaption("%s", l.Caption)
+	}
+	t.Render()
+	return nil
+}
+
+// display 表格中显示的值，styled 为 false 时（Markdown 或关闭颜色）去掉 Display 中的颜色
+func (l *Listing) display(i int, v any, styled bool) any {
+	c := l.Columns[i]
+	if c.Display == nil {
+		return v
+	}
+	if !styled {
+		return text.StripEscape(c.Display(v))
+	}
+	return c.Display(v)
+}
+
+// marshalJSON 不转义 <、>、& 的 JSON 编码，版本约束等值保持原样，结果以换行结尾
+func marshalJSON(v any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// orderedRecord 保持列顺序的 JSON 对象
+type orderedRecord struct {
+	keys   []string
+	values []any
+}
+
+func (r orderedRecord) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := marshalJSON(k, "")
+		value, err := marshalJSON(r.values[i], "")
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(bytes.TrimSpace(key))
+		buf.WriteByte(':')
+		buf.Write(bytes.TrimSpace(value))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (l *Listing) records(idx []int) []orderedRecord {
+	records := make([]orderedRecord, 0, len(l.Rows))
+	for _, row := range l.Rows {
+		r := orderedRecord{}
+		for _, i := range idx {
+			r.keys = append(r.keys, l.Columns[i].Key)
+			r.values = append(r.values, row[i])
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// yamlNode 构造保持列顺序的 YAML 序列
+func (l *Listing) yamlNode(idx []int) *yaml.Node {
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, row := range l.Rows {
+		m := &yaml.Node{Kind: yaml.MappingNode}
+		for _, i := range idx {
+			var value yaml.Node
+			_ = value.Encode(row[i])
+			m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: l.Columns[i].Key}, &value)
+		}
+		seq.Content = append(seq.Content, m)
+	}
+	return seq
+}
@@ -1,26 +1,32 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
-	"github.com/jedib0t/go-pretty/v6/table"
-	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/spf13/cobra"
 
 	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
 	"github.com/bookandmusic/tool/internal/plugins"
 	"github.com/bookandmusic/tool/internal/utils"
 )
 
-type PluginService struct{}
+// PluginService soft 插件管理命令，Registry 为插件所在的注册表，Runtime 为所属 App 的运行时状态
+type PluginService struct {
+	Runtime  *common.Runtime
+	Registry *plugins.Registry
+}
 
 func (p *PluginService) enabled(args []string) error {
-	console := common.GlobalCfg.Logger
-	cfg := common.GlobalCfg.Cfg
-	cfgPath := common.GlobalCfg.CfgPath
+	console := p.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	cfg := p.Runtime.Cfg
+	cfgPath := p.Runtime.CfgPath
 
 	if len(args) == 0 {
-		console.Error("[PLUGIN] No plugin name specified")
+		console.Error("No plugin name specified")
 		return nil
 	}
 
@@ -28,37 +34,59 @@ func (p *PluginService) enabled(args []string) error {
 		cfg.Plugins = make(map[string]common.PluginConfig)
 	}
 
+	// 任意插件校验失败时不修改配置
+	toEnable := map[string]*common.Meta{}
+	var errs []error
 	for _, name := range args {
 		meta, err := p.validateSoftPlugin(name)
 		if err != nil {
+			errs = append(errs, err)
 			continue
 		}
 
-		if p.isPluginEnabled(cfg, name) {
-			console.Debug(fmt.Sprintf("[PLUGIN] Plugin '%s' already enabled, skipping\n", name))
+		// 通过 namespace/name 指定时按注册名称记录
+		if p.isPluginEnabled(cfg, meta.Name) {
+			console.Debug("Plugin '%s' already enabled, skipping", meta.Name)
 			continue
 		}
-
-		p.enablePlugin(cfg, name, meta)
-		console.Info("[PLUGIN] Plugin '%s' enabled", name)
+		toEnable[meta.Name] = meta
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if len(toEnable) == 0 {
+		console.Info("All plugins are already enabled")
+		return nil
 	}
 
-	if err := common.SaveConfig(cfgPath, cfg); err != nil {
+	// 只修改用户配置文件，合并后的配置同步更新
+	err := common.UpdateConfig(cfgPath, p.Runtime.Env, func(fileCfg *common.Config) error {
+		for name, meta := range toEnable {
+			if !p.isPluginEnabled(fileCfg, name) {
+				p.enablePlugin(fileCfg, name, meta)
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
-	common.GlobalCfg.Cfg = cfg
+	for name, meta := range toEnable {
+		p.enablePlugin(cfg, name, meta)
+		console.Info("Plugin '%s' enabled", name)
+	}
 
-	console.Success("[PLUGIN] Plugins enabled successfully. You can modify parameters manually in the config file.")
+	console.Success("Plugins enabled successfully. You can modify parameters manually in the config file.")
 	return nil
 }
 
 func (p *PluginService) disable(args []string) error {
-	console := common.GlobalCfg.Logger
-	cfg := common.GlobalCfg.Cfg
-	cfgPath := common.GlobalCfg.CfgPath
+	console := p.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	cfg := p.Runtime.Cfg
+	cfgPath := p.Runtime.CfgPath
 
 	if len(args) == 0 {
-		console.Error("[PLUGIN] No plugin name specified to disable.")
+		console.Error("No plugin name specified to disable.")
 		return nil
 	}
 
@@ -67,67 +95,103 @@ func (p *PluginService) disable(args []string) error {
 
 	for _, name := range args {
 		if _, ok := enabledSet[name]; !ok {
-			console.Warning("[PLUGIN] Plugin '%s' not enabled, skipping", name)
+			console.Warning("Plugin '%s' not enabled, skipping%s", name, utils.DidYouMean(utils.Suggest(name, cfg.EnabledPlugins, maxSuggestions)))
 			continue
 		}
-		p.removePlugin(cfg, name)
 		removed = append(removed, name)
 	}
 
-	if len(removed) > 0 {
-		if err := common.SaveConfig(cfgPath, cfg); err != nil {
-			return err
-		}
+	if len(removed) == 0 {
+		console.Info("No plugins were disabled")
+		return nil
+	}
+
+	inFile := map[string]bool{}
+	err := common.UpdateConfig(cfgPath, p.Runtime.Env, func(fileCfg *common.Config) error {
 		for _, name := range removed {
-			console.Success("[PLUGIN] Plugin '%s' disabled", name)
+			inFile[name] = p.isPluginEnabled(fileCfg, name)
+			p.removePlugin(fileCfg, name)
 		}
-	} else {
-		console.Info("[PLUGIN] No plugins were disabled")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range removed {
+		if !inFile[name] {
+			// 由系统/项目配置或环境变量启用，用户配置无法禁用
+			console.Warning("Plugin '%s' is enabled by %s, remove it there to disable", name, p.enabledOrigin(name))
+			continue
+		}
+		p.removePlugin(cfg, name)
+		console.Success("Plugin '%s' disabled", name)
 	}
 
 	return nil
 }
 
+// enabledOrigin 返回启用某个插件的配置来源
+func (p *PluginService) enabledOrigin(name string) string {
+	layered := p.Runtime.Layered
+	if layered == nil {
+		return "another config layer"
+	}
+	for i, n := range p.Runtime.Cfg.EnabledPlugins {
+		if n == name {
+			if origin := layered.LookupOrigin(fmt.Sprintf("enabled_plugins[%d]", i)); origin != "" {
+				return origin
+			}
+		}
+	}
+	return "another config layer"
+}
+
 // ------------------- PluginService 辅助方法 -------------------
 
 func (p *PluginService) validateSoftPlugin(name string) (*common.Meta, error) {
-	console := common.GlobalCfg.Logger
-	meta := plugins.GetMetaByName(name)
+	console := p.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	meta := p.Registry.Get(name)
 	if meta == nil {
-		console.Error("[PLUGIN] Plugin '%s' not found\n", name)
-		return nil, fmt.Errorf("plugin '%s' not found", name)
+		suggestion := suggestPlugin(p.Registry, name, isSoftPlugin)
+		console.Error("Plugin '%s' not found%s", name, suggestion)
+		return nil, fmt.Errorf("plugin '%s' not found%s", name, suggestion)
 	}
 	if meta.Type != common.Soft {
-		console.Error("[PLUGIN] Plugin '%s' is not a soft plugin and cannot be enabled\n", name)
+		console.Error("Plugin '%s' is not a soft plugin and cannot be enabled", name)
 		return nil, fmt.Errorf("plugin '%s' is not a soft plugin", name)
 	}
+	if meta.Unsupported != "" {
+		console.Error("Plugin '%s' cannot be enabled on this host: %s", name, meta.Unsupported)
+		return nil, fmt.Errorf("plugin '%s' is unsupported: %s", name, meta.Unsupported)
+	}
 	if meta.GetCommand("install") == nil || meta.GetCommand("uninstall") == nil {
-		console.Error("[PLUGIN] Soft plugin '%s' must define 'install' and 'uninstall' commands in meta.yml\n", name)
+		console.Error("Soft plugin '%s' must define 'install' and 'uninstall' commands in meta.yml", name)
 		return nil, fmt.Errorf("soft plugin '%s' missing install/uninstall commands", name)
 	}
-	console.Debug(fmt.Sprintf("[PLUGIN] Plugin '%s' validated successfully\n", name))
+	console.Debug("Plugin '%s' validated successfully", name)
 	return meta, nil
 }
 
 func (p *PluginService) isPluginEnabled(cfg *common.Config, name string) bool {
-	for _, n := range cfg.EnabledPlugins {
-		if n == name {
-			return true
-		}
-	}
-	return false
+	return slices.Contains(cfg.EnabledPlugins, name)
 }
 
-func (p *PluginService) enablePlugin(cfg *common.Config, name string, meta *common.Meta) {
-	installDef := meta.GetCommand("install")
-	uninstallDef := meta.GetCommand("uninstall")
+// metaDefaults 返回插件 install/uninstall 的默认 flags（子命令 flags 优先，通用 flags 补充）
+func (p *PluginService) metaDefaults(meta *common.Meta) *common.PluginDefaults {
 	globalFlags := utils.CmdFlagsToMap(meta.Flags)
-	installFags := utils.CmdFlagsToMap(installDef.Flags)
-	uninstallFlags := utils.CmdFlagsToMap(uninstallDef.Flags)
+	return &common.PluginDefaults{
+		Install:   utils.MergeFlags(utils.CmdFlagsToMap(meta.GetCommand("install").Flags), globalFlags, "fill"),
+		Uninstall: utils.MergeFlags(utils.CmdFlagsToMap(meta.GetCommand("uninstall").Flags), globalFlags, "fill"),
+	}
+}
 
+func (p *PluginService) enablePlugin(cfg *common.Config, name string, meta *common.Meta) {
+	// 配置值和记录的默认值使用不同的 map，避免后续修改配置时影响记录
+	values := p.metaDefaults(meta)
 	cfg.Plugins[name] = common.PluginConfig{
-		Install:   utils.MergeFlags(installFags, globalFlags, "fill"),
-		Uninstall: utils.MergeFlags(uninstallFlags, globalFlags, "fill"),
+		Install:   values.Install,
+		Uninstall: values.Uninstall,
+		Defaults:  p.metaDefaults(meta),
 	}
 	cfg.EnabledPlugins = append(cfg.EnabledPlugins, name)
 }
@@ -153,93 +217,225 @@ func (p *PluginService) removePlugin(cfg *common.Config, name string) {
 }
 
 func (p *PluginService) list() error {
-	cfg := common.GlobalCfg.Cfg
-	console := common.GlobalCfg.Logger
-
-	// 创建 table
-	t := table.NewWriter()
-	t.SetOutputMirror(console.Writer())
-	t.AppendHeader(table.Row{"Plugin Name", "Type", "Status"})
-
-	// 表头加粗
-	t.Style().Format.Header = text.FormatDefault
-	t.Style().Options.DrawBorder = false      // 去掉边框
-	t.Style().Options.SeparateColumns = false // 去掉列分隔
-	t.Style().Options.SeparateRows = false    // 去掉行分隔
-
-	// 准备数据行
-	for _, meta := range plugins.ListManyByType(common.Soft) {
-		status := "Disabled"
-		if contains(cfg.EnabledPlugins, meta.Name) {
-			status = "Enabled"
-		}
+	cfg := p.Runtime.Cfg
+	console := p.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	layered := p.Runtime.Layered
 
-		// 状态列彩色显示
-		var statusCell string
-		if status == "Enabled" {
-			statusCell = text.FgGreen.Sprint(status)
-		} else {
-			statusCell = text.FgHiBlack.Sprint(status)
+	listing := &Listing{
+		Columns: append(append([]Column{}, pluginColumns...), Column{Key: "enabled_by", Header: "Enabled By"}),
+		Default: []string{"name", "type", "version", "status"},
+	}
+	if layered != nil && layered.Profile != "" {
+		// 使用 profile 时显示插件由哪个配置层或 profile 启用
+		listing.Default = append(listing.Default, "enabled_by")
+		listing.Caption = "profile: " + layered.Profile
+	}
+
+	for _, meta := range p.Registry.ListByType(common.Soft) {
+		row := pluginRow(meta, cfg)
+		enabledBy := ""
+		if slices.Contains(cfg.EnabledPlugins, meta.Name) {
+			enabledBy = p.enabledOrigin(meta.Name)
+		}
+		listing.AppendRow(append(row, enabledBy)...)
+		if meta.Unsupported != "" {
+			console.Debug("Plugin '%s' unsupported: %s", meta.Name, meta.Unsupported)
 		}
+		console.Trace("Plugin '%s' status: %s", meta.Name, row[2])
+	}
+	return listing.Render(p.Runtime, console)
+}
+
+// sync 把 meta.yml 中新增、删除、修改的 flag 默认值合并到用户配置，
+// 只修改用户未改动过的值；dryRun 时只打印变更
+func (p *PluginService) sync(cmd *cobra.Command, cmdParams *common.CmdParams, args []string) error {
+	console := p.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	cfgPath := p.Runtime.CfgPath
 
-		t.AppendRow(table.Row{meta.Name, meta.Type.String(), statusCell})
-		console.Debug(fmt.Sprintf("[PLUGIN] Plugin '%s' status: %s\n", meta.Name, status))
+	flags, err := utils.MergeFlagsAndArgs(cmdParams.Flags, nil, cmd)
+	if err != nil {
+		return err
 	}
+	dryRun, _ := flags["dry-run"].(bool)
 
-	t.Render() // 渲染表格
+	// 只同步用户配置文件，其他配置层中的插件配置由各自维护者负责
+	fileCfg, err := common.LoadConfig(cfgPath)
+	if err != nil {
+		console.Error("Failed to load config file %s: %v", cfgPath, err)
+		return err
+	}
+	names := args
+	if len(names) == 0 {
+		names = fileCfg.EnabledPlugins
+	}
+	updated := false
+	for _, name := range names {
+		if p.syncPlugin(fileCfg, name) {
+			updated = true
+		}
+	}
 
+	switch {
+	case !updated:
+		console.Info("Plugin config is up to date")
+	case dryRun:
+		console.Info("Dry run, %s not modified", cfgPath)
+	default:
+		if err := common.SaveConfig(cfgPath, fileCfg); err != nil {
+			return err
+		}
+		console.Success("Plugin config synced to %s", cfgPath)
+	}
 	return nil
 }
 
-// 判断 slice 是否包含某个元素
-func contains(list []string, s string) bool {
-	for _, item := range list {
-		if item == s {
-			return true
+// syncPlugin 对单个插件做三方合并并打印变更，返回配置是否有变化
+func (p *PluginService) syncPlugin(cfg *common.Config, name string) bool {
+	console := p.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	meta, err := p.validateSoftPlugin(name)
+	if err != nil {
+		return false
+	}
+	pc, ok := cfg.Plugins[name]
+	if !ok {
+		console.Warning("Plugin '%s' has no config in %s, skipping", name, p.Runtime.CfgPath)
+		return false
+	}
+	var base common.PluginDefaults
+	if pc.Defaults != nil {
+		base = *pc.Defaults
+	} else {
+		console.Warning("Plugin '%s' has no recorded defaults, values differing from meta.yml are treated as customized", name)
+	}
+	latest := p.metaDefaults(meta)
+
+	// 按 meta.yml 中声明的 flag 类型比较，YAML 中的 1 与 1.0、"1" 等写法视为相同
+	normalizer := func(command string) utils.FlagNormalizer {
+		return func(key string, v any) any {
+			return utils.NormalizeFlagValue(utils.FindFlag(meta, command, key), v)
+		}
+	}
+	installNorm, uninstallNorm := normalizer("install"), normalizer("uninstall")
+
+	var installChanges, uninstallChanges []utils.FlagChange
+	pc.Install, installChanges = utils.ThreeWayMerge(base.Install, pc.Install, latest.Install, installNorm)
+	pc.Uninstall, uninstallChanges = utils.ThreeWayMerge(base.Uninstall, pc.Uninstall, latest.Uninstall, uninstallNorm)
+
+	changed := pc.Defaults == nil ||
+		!utils.SameFlags(pc.Defaults.Install, latest.Install, installNorm) ||
+		!utils.SameFlags(pc.Defaults.Uninstall, latest.Uninstall, uninstallNorm)
+	for _, c := range installChanges {
+		console.Info("%s install %s", name, c)
+		changed = changed || c.Kind != utils.FlagKept
+	}
+	for _, c := range uninstallChanges {
+		console.Info("%s uninstall %s", name, c)
+		changed = changed || c.Kind != utils.FlagKept
+	}
+	if !changed {
+		console.Debug("Plugin '%s' config is up to date", name)
+		return false
+	}
+	pc.Defaults = latest
+	cfg.Plugins[name] = pc
+	return true
+}
+
+// resolveKwargs 解析配置值中的 ${env:...}/${config:...}/${file:...}/${cmd:...} 表达式
+func resolveKwargs(rt *common.Runtime, kwargs map[string]any) (map[string]any, error) {
+	cfg := rt.Cfg
+	shell := ""
+	if cfg.Executor != nil {
+		shell = cfg.Executor.Shell
+	}
+	resolver := utils.NewResolver(shell, rt.Env, rt.Secrets, func(path string) (any, error) {
+		return common.GetConfigValue(cfg, path)
+	})
+	return resolver.ResolveMap(kwargs)
+}
+
+// runSoftAction 对单个 soft 插件执行 install/uninstall，dryRun 时只打印命令
+func (p *PluginService) runSoftAction(cmd *cobra.Command, meta *common.Meta, action string, args []string, dryRun bool) error {
+	name := meta.Name
+	console := p.Runtime.Logger.With(logger.KeyComponent, "plugin", "plugin", name, "command", action)
+	cfg := p.Runtime.Cfg
+
+	// 找到对应的 install/uninstall 子命令
+	subCmd := meta.GetCommand(action)
+	if subCmd == nil {
+		console.Error("Soft plugin is missing the %s command", action)
+		return nil
+	}
+	var kwargs map[string]any
+	if action == "install" {
+		kwargs = cfg.Plugins[name].Install
+	} else {
+		kwargs = cfg.Plugins[name].Uninstall
+	}
+	kwargs, err := resolveKwargs(p.Runtime, kwargs)
+	if err != nil {
+		console.Error("Failed to resolve config values: %v", err)
+		return err
+	}
+	cmdParams := &common.CmdParams{
+		Name:  subCmd.Name,
+		Flags: utils.CmdFlagsToMap(subCmd.Flags),
+	}
+	// soft install/uninstall 自身的 flag（如 --dry-run）不属于插件，
+	// 插件使用只携带 context 的空命令，保证预览与实际执行的参数一致
+	pluginCmd := &cobra.Command{}
+	pluginCmd.SetContext(cmd.Context())
+
+	if dryRun {
+		builder, ok := meta.Service.(common.CommandBuilder)
+		if !ok {
+			console.Info("Dry run: builtin handler")
+			return nil
+		}
+		argv, err := builder.BuildCommand(pluginCmd, cmdParams, args, kwargs)
+		if err != nil {
+			return err
 		}
+		console.Info("Dry run: %s", p.Runtime.Secrets.Mask(strings.Join(argv, " ")))
+		return nil
+	}
+
+	// 执行插件的处理函数
+	console.Debug("Executing plugin command")
+	if err := meta.Service.Handler(pluginCmd, cmdParams, args, kwargs); err != nil {
+		console.Error("Plugin command failed: %v", err)
+		return err
 	}
-	return false
+	console.Debug("Plugin command executed successfully")
+	return nil
 }
 
-func (e *PluginService) initOrDestroy(cmd *cobra.Command, action string, args []string) error {
-	console := common.GlobalCfg.Logger
-	cfg := common.GlobalCfg.Cfg
+func (p *PluginService) initOrDestroy(cmd *cobra.Command, cmdParams *common.CmdParams, action string, args []string) error {
+	console := p.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	cfg := p.Runtime.Cfg
 	enabledPlugins := cfg.EnabledPlugins
 	if len(enabledPlugins) == 0 {
-		console.Warning("[PLUGIN] No active plugins, use: tool plugin enable [plugin-name]")
+		console.Warning("No active plugins, use: tool soft enable [plugin-name]")
 		return nil
 	}
+	flags, err := utils.MergeFlagsAndArgs(cmdParams.Flags, nil, cmd)
+	if err != nil {
+		return err
+	}
+	dryRun, _ := flags["dry-run"].(bool)
+
 	for _, name := range enabledPlugins {
-		meta := plugins.GetMetaByName(name)
+		meta := p.Registry.Get(name)
 		if meta == nil || meta.Type != common.Soft {
 			continue
 		}
-
-		// 找到对应的 install/uninstall 子命令
-
-		subCmd := meta.GetCommand(action)
-		if subCmd == nil {
-			console.Error("[PLUGIN] Soft plugin '%s' missing %s command", name, action)
+		if meta.Unsupported != "" {
+			console.Warning("Skipping plugin '%s', unsupported on this host: %s", name, meta.Unsupported)
 			continue
 		}
-		var kwargs map[string]any
-		if action == "install" {
-			kwargs = cfg.Plugins[name].Install
-		} else {
-			kwargs = cfg.Plugins[name].Uninstall
-		}
-		flags := utils.CmdFlagsToMap(subCmd.Flags)
-
-		// 执行插件的处理函数
-		console.Debug(fmt.Sprintf("[PLUGIN] Executing plugin '%s' %s command\n", name, action))
-		if err := meta.Service.Handler(cmd, &common.CmdParams{
-			Name:  subCmd.Name,
-			Flags: flags,
-		}, args, kwargs); err != nil {
-			console.Error("[PLUGIN] Plugin '%s' %s failed: %v", name, action, err)
+		if err := p.runSoftAction(cmd, meta, action, args, dryRun); err != nil {
 			return err
 		}
-		console.Debug(fmt.Sprintf("[PLUGIN] Plugin '%s' %s executed successfully\n", name, action))
 	}
 	return nil
 }
@@ -255,9 +451,11 @@ func (p *PluginService) Handler(cmd *cobra.Command, cmdParams *common.CmdParams,
 	case "ls":
 		return p.list()
 	case "install":
-		return p.initOrDestroy(cmd, "install", args)
+		return p.initOrDestroy(cmd, cmdParams, "install", args)
 	case "destroy":
-		return p.initOrDestroy(cmd, "uninstall", args)
+		return p.initOrDestroy(cmd, cmdParams, "uninstall", args)
+	case "sync [name]":
+		return p.sync(cmd, cmdParams, args)
 	}
 
 	return nil
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+	"github.com/bookandmusic/tool/internal/plugins"
+)
+
+// newRuntime 创建测试使用的 Runtime，返回记录日志的 buffer
+func newRuntime(cfg *common.Config) (*common.Runtime, *bytes.Buffer) {
+	var diag bytes.Buffer
+	rt := &common.Runtime{
+		Cfg:     cfg,
+		Logger:  logger.NewLogger(io.Discard, &diag, logger.LevelInfo, &logger.TextFormatter{}),
+		Secrets: common.NewSecrets(),
+	}
+	return rt, &diag
+}
+
+func softMeta(rt *common.Runtime) *common.Meta {
+	return &common.Meta{
+		Name: "sp",
+		Type: common.Soft,
+		Commands: []common.CommandDef{{
+			Name: "install",
+			Flags: []*common.CommandFlag{
+				{Name: "dry-run", Type: common.FlagBool, Default: false},
+				{Name: "ver", Default: "1"},
+			},
+		}},
+		Service: &ExtraService{Runtime: rt, PluginName: "sp", Exec: "run.sh"},
+	}
+}
+
+func TestRunSoftActionDryRunIgnoresSoftFlags(t *testing.T) {
+	rt, diag := newRuntime(&common.Config{Plugins: map[string]common.PluginConfig{}})
+
+	// soft install 自身的 --dry-run 与插件 flag 同名
+	installCmd := &cobra.Command{Use: "install"}
+	installCmd.Flags().Bool("dry-run", false, "")
+	if err := installCmd.Flags().Set("dry-run", "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &PluginService{Runtime: rt, Registry: plugins.NewRegistry()}
+	if err := p.runSoftAction(installCmd, softMeta(rt), "install", nil, true); err != nil {
+		t.Fatal(err)
+	}
+	out := diag.String()
+	if !strings.Contains(out, "Dry run: ./run.sh install --ver=1") {
+		t.Errorf("dry run output = %q, want plugin argv", out)
+	}
+	if strings.Contains(out, "--dry-run") {
+		t.Errorf("dry run output = %q, soft install's --dry-run leaked into the plugin argv", out)
+	}
+}
+
+func enableFixture(t *testing.T) (*PluginService, *bytes.Buffer, string) {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), "tool.yml")
+	rt, diag := newRuntime(&common.Config{Plugins: map[string]common.PluginConfig{}})
+	rt.CfgPath = cfgPath
+
+	reg := plugins.NewRegistry()
+	meta := softMeta(rt)
+	meta.Commands = append(meta.Commands, common.CommandDef{Name: "uninstall"})
+	reg.MustRegister(meta)
+	return &PluginService{Runtime: rt, Registry: reg}, diag, cfgPath
+}
+
+func TestEnableUnknownPlugin(t *testing.T) {
+	p, diag, cfgPath := enableFixture(t)
+
+	err := p.enabled([]string{"sp", "spp"})
+	if err == nil || !strings.Contains(err.Error(), "plugin 'spp' not found, did you mean 'sp'?") {
+		t.Fatalf("enabled() error = %v, want not found with suggestion", err)
+	}
+	if strings.Contains(diag.String(), "successfully") {
+		t.Errorf("success printed for unknown plugin: %q", diag.String())
+	}
+	if _, err := os.Stat(cfgPath); !os.IsNotExist(err) {
+		t.Errorf("config written although a plugin was not found: %v", err)
+	}
+	if len(p.Runtime.Cfg.EnabledPlugins) != 0 {
+		t.Errorf("EnabledPlugins = %v, want none", p.Runtime.Cfg.EnabledPlugins)
+	}
+}
+
+func TestEnablePlugin(t *testing.T) {
+	p, diag, cfgPath := enableFixture(t)
+
+	if err := p.enabled([]string{"sp"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := common.LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.EnabledPlugins) != 1 || cfg.EnabledPlugins[0] != "sp" {
+		t.Errorf("EnabledPlugins = %v, want [sp]", cfg.EnabledPlugins)
+	}
+	if !strings.Contains(diag.String(), "Plugins enabled successfully") {
+		t.Errorf("output = %q, want success message", diag.String())
+	}
+
+	diag.Reset()
+	if err := p.enabled([]string{"sp"}); err != nil {
+		t.Fatal(err)
+	}
+	if out := diag.String(); strings.Contains(out, "successfully") || !strings.Contains(out, "already enabled") {
+		t.Errorf("output = %q, want already enabled without success message", out)
+	}
+}
+
+func TestPluginListVersionOutput(t *testing.T) {
+	cfg := &common.Config{Plugins: map[string]common.PluginConfig{}}
+	meta := &common.Meta{Name: "nover", Type: common.Command}
+	for _, tt := range []struct {
+		format string
+		want   string
+	}{
+		{common.OutputJSON, `"version": ""`},
+		{common.OutputCSV, "nover,command,"},
+		{common.OutputMarkdown, "| - |"},
+	} {
+		var out bytes.Buffer
+		rt := &common.Runtime{
+			Cfg:     cfg,
+			Logger:  logger.NewLogger(&out, io.Discard, logger.LevelInfo, &logger.TextFormatter{}),
+			Output:  tt.format,
+			Columns: []string{"name", "type", "version"},
+		}
+		listing := &Listing{Columns: pluginColumns}
+		listing.AppendRow(pluginRow(meta, cfg)...)
+		if err := listing.Render(rt, rt.Logger); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if !strings.Contains(out.String(), tt.want) {
+			t.Errorf("%s output = %q, want %q", tt.format, out.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/plugins"
+	"github.com/bookandmusic/tool/internal/utils"
+)
+
+// maxSuggestions "did you mean" 最多给出的建议数
+const maxSuggestions = 3
+
+// suggestPlugin 在注册表中查找与 name 相近的插件名，match 为空时匹配所有插件
+func suggestPlugin(reg *plugins.Registry, name string, match func(meta *common.Meta) bool) string {
+	var names []string
+	for _, meta := range reg.List() {
+		if match == nil || match(meta) {
+			names = append(names, meta.Name)
+		}
+	}
+	return utils.DidYouMean(utils.Suggest(name, names, maxSuggestions))
+}
+
+func isSoftPlugin(meta *common.Meta) bool {
+	return meta.Type == common.Soft
+}
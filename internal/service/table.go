@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+)
+
+// newTable 创建统一风格的无边框表格，输出到 console
+func newTable(console logger.Logger) table.Writer {
+	t := table.NewWriter()
+	t.SetOutputMirror(console.Writer())
+
+	t.Style().Format.Header = text.FormatDefault
+	t.Style().Options.DrawBorder = false      // 去掉边框
+	t.Style().Options.SeparateColumns = false // 去掉列分隔
+	t.Style().Options.SeparateRows = false    // 去掉行分隔
+	return t
+}
+
+// 插件状态，用于列表的 status 字段
+const (
+	StatusEnabled     = "enabled"     // soft 插件已启用
+	StatusDisabled    = "disabled"    // soft 插件未启用
+	StatusAvailable   = "available"   // command 插件可用
+	StatusUnsupported = "unsupported" // 当前主机不支持
+	StatusFailed      = "failed"      // 加载失败或与当前工具版本不兼容
+	StatusShadowed    = "shadowed"    // 与其他插件重名，未生效
+)
+
+// pluginColumns 插件列表的字段，json/yaml/csv 输出使用这些稳定的字段名：
+//
+//	name          插件名称
+//	type          插件类型：command / soft
+//	status        enabled / disabled / available / unsupported / failed / shadowed
+//	version       插件版本，内置插件为工具版本，未声明为空（表格中显示为 "-"）
+//	dir           插件目录，内置插件为空
+//	builtin       是否为内置插件
+//	description   插件描述
+//	requires_tool 依赖的工具版本约束，未声明为空
+var pluginColumns = []Column{
+	{Key: "name", Header: "Plugin Name"},
+	{Key: "type", Header: "Type"},
+	{Key: "status", Header: "Status", Display: displayStatus},
+	{Key: "version", Header: "Version", Display: displayOptional},
+	{Key: "dir", Header: "Dir"},
+	{Key: "builtin", Header: "Source", Display: func(v any) string {
+		if b, _ := v.(bool); b {
+			return "builtin"
+		}
+		return "extra"
+	}},
+	{Key: "description", Header: "Description"},
+	{Key: "requires_tool", Header: "Requires Tool", Display: displayOptional},
+}
+
+// displayOptional 空值在表格中显示为 "-"
+func displayOptional(v any) string {
+	if v == "" || v == nil {
+		return "-"
+	}
+	return fmt.Sprint(v)
+}
+
+// pluginStatus 返回插件在当前配置下的状态
+func pluginStatus(meta *common.Meta, cfg *common.Config) string {
+	switch {
+	case meta.Unsupported != "":
+		return StatusUnsupported
+	case meta.Type != common.Soft:
+		return StatusAvailable
+	case slices.Contains(cfg.EnabledPlugins, meta.Name):
+		return StatusEnabled
+	default:
+		return StatusDisabled
+	}
+}
+
+// pluginRow 按 pluginColumns 的顺序返回插件的一行数据
+func pluginRow(meta *common.Meta, cfg *common.Config) []any {
+	return []any{
+		meta.Name,
+		meta.Type.String(),
+		pluginStatus(meta, cfg),
+		pluginVersion(meta),
+		meta.Dir,
+		meta.BuiltIn,
+		meta.Desc,
+		meta.RequiresTool,
+	}
+}
+
+// displayStatus 表格中状态列首字母大写并着色，不依赖 go-pretty 的全局颜色开关，
+// 关闭颜色时由 Listing.display 去掉转义序列
+func displayStatus(v any) string {
+	status := fmt.Sprint(v)
+	label := strings.ToUpper(status[:1]) + status[1:]
+	c := text.FgHiBlack
+	switch status {
+	case StatusEnabled, StatusAvailable:
+		c = text.FgGreen
+	case StatusUnsupported, StatusShadowed:
+		c = text.FgYellow
+	case StatusFailed:
+		c = text.FgRed
+	}
+	return c.EscapeSeq() + label + text.Reset.EscapeSeq()
+}
+
+// pluginVersion 返回插件版本，内置插件随工具版本，未声明时为空
+func pluginVersion(meta *common.Meta) string {
+	if meta.BuiltIn {
+		return common.ToolVersion()
+	}
+	return meta.Version
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+	"github.com/bookandmusic/tool/internal/plugins"
+	"github.com/bookandmusic/tool/internal/schema"
+	"github.com/bookandmusic/tool/internal/utils"
+)
+
+// ToolConfigService 配置查看与管理命令
+// 读取操作基于合并后的有效配置，写入操作只修改用户配置文件
+type ToolConfigService struct {
+	Runtime  *common.Runtime
+	Registry *plugins.Registry
+}
+
+func (s *ToolConfigService) show(cmd *cobra.Command, cmdParams *common.CmdParams) error {
+	console := s.Runtime.Logger
+	cfg := s.Runtime.Cfg
+
+	flags, err := utils.MergeFlagsAndArgs(cmdParams.Flags, nil, cmd)
+	if err != nil {
+		return err
+	}
+	if origin, _ := flags["origin"].(bool); !origin {
+		return s.printConfig(console, cfg)
+	}
+
+	entries, err := common.FlattenConfig(cfg)
+	if err != nil {
+		return err
+	}
+	listing := &Listing{Columns: []Column{
+		{Key: "key", Header: "Key"},
+		{Key: "value", Header: "Value"},
+		{Key: "origin", Header: "Origin", Display: displayOptional},
+	}}
+	for _, e := range entries {
+		origin := ""
+		if layered := s.Runtime.Layered; layered != nil {
+			if o := layered.LookupOrigin(e.Path); o != "" {
+				origin = o
+			}
+		}
+		listing.AppendRow(e.Path, e.Value, origin)
+	}
+	return listing.Render(s.Runtime, console)
+}
+
+// printConfig 输出完整的配置文档：默认和 -o yaml 为 YAML，-o json 为 JSON；
+// 其他格式和 --columns 只适用于 --origin 的逐项列表
+func (s *ToolConfigService) printConfig(console logger.Logger, cfg *common.Config) error {
+	format := s.Runtime.Output
+	if len(s.Runtime.Columns) > 0 || (format != "" && format != common.OutputTable && format != common.OutputYAML && format != common.OutputJSON) {
+		err := fmt.Errorf("config show without --origin supports only -o %s or -o %s, -o %s and --columns require --origin", common.OutputYAML, common.OutputJSON, format)
+		console.Error("%v", err)
+		return err
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if format == common.OutputJSON {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if data, err = marshalJSON(doc, "  "); err != nil {
+			return err
+		}
+	}
+	console.Print(string(data))
+	return nil
+}
+
+func (s *ToolConfigService) get(args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "config")
+	if len(args) != 1 {
+		console.Error("Usage: tool config get <key>")
+		return fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	value, err := common.GetConfigValue(s.Runtime.Cfg, args[0])
+	if err != nil {
+		console.Error("%v", err)
+		return err
+	}
+	switch value.(type) {
+	case map[string]any, []any:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		console.Print(string(data))
+	default:
+		console.Print(fmt.Sprintf("%v\n", value))
+	}
+	return nil
+}
+
+// pluginFlag 路径形如 plugins.<name>.<install|uninstall>.<flag> 时返回 meta.yml 中声明的 flag
+func pluginFlag(reg *plugins.Registry, segs []common.PathSegment) *common.CommandFlag {
+	if len(segs) != 4 || segs[0].Key != "plugins" {
+		return nil
+	}
+	action := segs[2].Key
+	if action != "install" && action != "uninstall" {
+		return nil
+	}
+	meta := reg.Get(segs[1].Key)
+	if meta == nil {
+		return nil
+	}
+	return utils.FindFlag(meta, action, segs[3].Key)
+}
+
+// coerceBySchema 按配置 Schema 中声明的类型转换字符串
+func coerceBySchema(sc *schema.Schema, raw string) (any, error) {
+	if sc == nil {
+		sc = &schema.Schema{}
+	}
+	switch sc.Type {
+	case "string":
+		return raw, nil
+	case "boolean":
+		return strconv.ParseBool(raw)
+	case "integer":
+		return strconv.Atoi(raw)
+	case "number":
+		return strconv.ParseFloat(raw, 64)
+	case "array":
+		items := []any{}
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			v, err := coerceBySchema(sc.Items, part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	}
+	// 未声明类型时按 YAML 标量规则推断
+	var v any
+	if err := yaml.Unmarshal([]byte(raw), &v); err != nil {
+		return raw, nil
+	}
+	return v, nil
+}
+
+// coerce 转换 config set 的值：插件 flag 按 meta.yml 声明的类型，其余按配置 Schema
+func (s *ToolConfigService) coerce(path, raw string) (any, error) {
+	segs, err := common.ParseConfigPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if flag := pluginFlag(s.Registry, segs); flag != nil {
+		v, err := utils.CoerceFlagValue(flag, raw)
+		if err != nil {
+			return nil, fmt.Errorf("flag '%s' expects %s: %w", flag.Name, utils.FlagKind(flag), err)
+		}
+		return v, nil
+	}
+	sc := common.ConfigSchema()
+	for _, seg := range segs {
+		if seg.IsIndex {
+			if sc != nil {
+				sc = sc.Items
+			}
+			continue
+		}
+		sc = sc.Property(seg.Key)
+	}
+	return coerceBySchema(sc, raw)
+}
+
+func (s *ToolConfigService) set(args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "config")
+	if len(args) != 2 {
+		console.Error("Usage: tool config set <key> <value>")
+		return fmt.Errorf("expected 2 arguments, got %d", len(args))
+	}
+	key := args[0]
+	value, err := s.coerce(key, args[1])
+	if err != nil {
+		console.Error("Invalid value for %s: %v", key, err)
+		return err
+	}
+	err = common.UpdateConfig(s.Runtime.CfgPath, s.Runtime.Env, func(cfg *common.Config) error {
+		return common.SetConfigValue(cfg, key, value)
+	})
+	if err != nil {
+		console.Error("Failed to set %s: %v", key, err)
+		return err
+	}
+	console.Success("%s = %v (%s)", key, value, s.Runtime.CfgPath)
+	return nil
+}
+
+func (s *ToolConfigService) unset(args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "config")
+	if len(args) != 1 {
+		console.Error("Usage: tool config unset <key>")
+		return fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	key := args[0]
+	err := common.UpdateConfig(s.Runtime.CfgPath, s.Runtime.Env, func(cfg *common.Config) error {
+		return common.UnsetConfigValue(cfg, key)
+	})
+	if err != nil {
+		console.Error("Failed to unset %s: %v", key, err)
+		return err
+	}
+	console.Success("%s removed (%s)", key, s.Runtime.CfgPath)
+	return nil
+}
+
+// editorCommand 返回 env 中 $VISUAL / $EDITOR 指定的编辑器，默认 vi
+func editorCommand(env common.Environ) []string {
+	for _, key := range []string{"VISUAL", "EDITOR"} {
+		value, _ := env.Lookup(key)
+		if fields := strings.Fields(value); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// edit 在临时副本上编辑配置，保存后校验通过才覆盖原文件
+func (s *ToolConfigService) edit() error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "config")
+	cfgPath := s.Runtime.CfgPath
+
+	original, err := os.ReadFile(filepath.Clean(cfgPath))
+	if os.IsNotExist(err) {
+		original, err = yaml.Marshal(common.GenerateDefault(s.Runtime.Env))
+	}
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "tool-*.yml")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	if _, err := tmp.Write(original); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	stdin := s.Runtime.Stdin
+	reader := bufio.NewReader(stdin)
+	for {
+		editor := editorCommand(s.Runtime.Env)
+		c := exec.Command(editor[0], append(editor[1:], tmpPath)...) // #nosec G204
+		c.Env = s.Runtime.Env.List()
+		c.Stdin, c.Stdout, c.Stderr = stdin, console.Writer(), console.ErrWriter()
+		if err := c.Run(); err != nil {
+			console.Error("Editor %s failed: %v", editor[0], err)
+			return err
+		}
+
+		_, err := common.LoadConfig(tmpPath)
+		if err == nil {
+			break
+		}
+		console.Error("Invalid configuration: %v", err)
+		console.Print("Re-open the editor to fix it? [Y/n] ")
+		answer, _ := reader.ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a == "n" || a == "no" {
+			console.Warning("Changes discarded, %s left untouched", cfgPath)
+			return fmt.Errorf("invalid configuration")
+		}
+	}
+
+	edited, err := os.ReadFile(filepath.Clean(tmpPath))
+	if err != nil {
+		return err
+	}
+	if string(edited) == string(original) {
+		console.Info("No changes")
+		return nil
+	}
+	if err := common.WriteFileAtomic(cfgPath, edited, 0o600); err != nil {
+		return err
+	}
+	console.Success("Saved %s", cfgPath)
+	return nil
+}
+
+// validate 校验每个配置层的文件，并检查合并后的配置引用的插件和 flag 是否存在
+func (s *ToolConfigService) validate() error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "config")
+	cfg := s.Runtime.Cfg
+	problems := 0
+
+	if layered := s.Runtime.Layered; layered != nil {
+		for _, layer := range layered.Layers {
+			if layer.Err != nil {
+				console.Error("%s: %v", layer.Path, layer.Err)
+				problems++
+				continue
+			}
+			console.Info("%s: ok", layer.Path)
+		}
+	}
+
+	for _, name := range cfg.EnabledPlugins {
+		meta := s.Registry.Get(name)
+		switch {
+		case meta == nil:
+			console.Error("enabled_plugins: plugin '%s' not found", name)
+			problems++
+		case meta.Type != common.Soft:
+			console.Error("enabled_plugins: plugin '%s' is not a soft plugin", name)
+			problems++
+		}
+	}
+	for name, pc := range cfg.Plugins {
+		meta := s.Registry.Get(name)
+		if meta == nil {
+			console.Warning("plugins.%s: plugin not found", name)
+			continue
+		}
+		for action, values := range map[string]map[string]any{"install": pc.Install, "uninstall": pc.Uninstall} {
+			for key := range values {
+				if utils.FindFlag(meta, action, key) == nil {
+					console.Warning("plugins.%s.%s.%s: unknown flag", name, action, key)
+				}
+			}
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("configuration has %d problem(s)", problems)
+	}
+	console.Success("Configuration is valid")
+	return nil
+}
+
+func (s *ToolConfigService) migrate(cmd *cobra.Command, cmdParams *common.CmdParams, args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "config")
+	path := s.Runtime.CfgPath
+	if len(args) > 0 {
+		path = args[0]
+	}
+	flags, err := utils.MergeFlagsAndArgs(cmdParams.Flags, nil, cmd)
+	if err != nil {
+		return err
+	}
+	dryRun, _ := flags["dry-run"].(bool)
+
+	res, err := common.MigrateConfigFile(path, dryRun)
+	if err != nil {
+		console.Error("Failed to migrate %s: %v", path, err)
+		return err
+	}
+	if len(res.Steps) == 0 {
+		console.Info("%s is already at version %d", path, res.To)
+		return nil
+	}
+	for _, step := range res.Steps {
+		console.Info("%s", step)
+	}
+	if dryRun {
+		console.Print(utils.LineDiff(string(res.Before), string(res.After)))
+		console.Info("Dry run, %s not modified", path)
+		return nil
+	}
+	console.Success("Migrated %s from v%d to v%d, backup saved to %s", path, res.From, res.To, res.Backup)
+	return nil
+}
+
+func (s *ToolConfigService) Handler(cmd *cobra.Command, cmdParams *common.CmdParams, args []string, kwargs map[string]any) error {
+	switch cmdParams.Name {
+	case "":
+		return cmd.Help()
+	case "get [key]":
+		return s.get(args)
+	case "set [key] [value]":
+		return s.set(args)
+	case "unset [key]":
+		return s.unset(args)
+	case "edit":
+		return s.edit()
+	case "migrate [cfg-path]":
+		return s.migrate(cmd, cmdParams, args)
+	case "show":
+		return s.show(cmd, cmdParams)
+	case "validate":
+		return s.validate()
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+	"github.com/bookandmusic/tool/internal/plugins"
+	"github.com/bookandmusic/tool/internal/schema"
+)
+
+func TestPrintConfigFormats(t *testing.T) {
+	cfg := &common.Config{Version: 1, PluginDirs: []string{"./plugins"}}
+	for _, tt := range []struct {
+		format  string
+		columns []string
+		want    string
+		wantErr bool
+	}{
+		{format: "", want: "plugin_dirs:\n    - ./plugins\n"},
+		{format: common.OutputYAML, want: "version: 1\n"},
+		{format: common.OutputJSON, want: `"plugin_dirs": [`},
+		{format: common.OutputCSV, wantErr: true},
+		{format: common.OutputYAML, columns: []string{"key"}, wantErr: true},
+	} {
+		var out bytes.Buffer
+		s := &ToolConfigService{Runtime: &common.Runtime{
+			Cfg:     cfg,
+			Logger:  logger.NewLogger(&out, io.Discard, logger.LevelInfo, &logger.TextFormatter{}),
+			Output:  tt.format,
+			Columns: tt.columns,
+		}}
+		err := s.printConfig(s.Runtime.Logger, cfg)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("-o %q --columns %v: expected error", tt.format, tt.columns)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("-o %q: %v", tt.format, err)
+		}
+		if !strings.Contains(out.String(), tt.want) {
+			t.Errorf("-o %q output = %q, want %q", tt.format, out.String(), tt.want)
+		}
+		if tt.format == common.OutputJSON && !json.Valid(out.Bytes()) {
+			t.Errorf("-o json output is not valid JSON: %q", out.String())
+		}
+	}
+}
+
+func TestCoerceBySchema(t *testing.T) {
+	tests := []struct {
+		sc   *schema.Schema
+		raw  string
+		want any
+	}{
+		{&schema.Schema{Type: "string"}, "123", "123"},
+		{&schema.Schema{Type: "boolean"}, "true", true},
+		{&schema.Schema{Type: "integer"}, "3", 3},
+		{&schema.Schema{Type: "number"}, "1.5", 1.5},
+		{&schema.Schema{Type: "array", Items: &schema.Schema{Type: "string"}}, "a, b,,c", []any{"a", "b", "c"}},
+		{&schema.Schema{Type: "array", Items: &schema.Schema{Type: "integer"}}, "1,2", []any{1, 2}},
+		{&schema.Schema{Type: "array"}, "", []any{}},
+		// 未声明类型时按 YAML 标量推断
+		{nil, "42", 42},
+		{&schema.Schema{}, "yes", "yes"},
+		{&schema.Schema{}, "false", false},
+		{&schema.Schema{}, "[unclosed", "[unclosed"},
+	}
+	for _, tt := range tests {
+		got, err := coerceBySchema(tt.sc, tt.raw)
+		if err != nil {
+			t.Errorf("coerceBySchema(%+v, %q): %v", tt.sc, tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("coerceBySchema(%+v, %q) = %#v, want %#v", tt.sc, tt.raw, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		sc  *schema.Schema
+		raw string
+	}{
+		{&schema.Schema{Type: "boolean"}, "maybe"},
+		{&schema.Schema{Type: "integer"}, "1.5"},
+		{&schema.Schema{Type: "number"}, "abc"},
+		{&schema.Schema{Type: "array", Items: &schema.Schema{Type: "integer"}}, "1,x"},
+	} {
+		if got, err := coerceBySchema(tt.sc, tt.raw); err == nil {
+			t.Errorf("coerceBySchema(%+v, %q) = %#v, want error", tt.sc, tt.raw, got)
+		}
+	}
+}
+
+func TestToolConfigCoerce(t *testing.T) {
+	reg := plugins.NewRegistry()
+	meta := &common.Meta{
+		Name: "sp",
+		Type: common.Soft,
+		Commands: []common.CommandDef{{
+			Name:  "install",
+			Flags: []*common.CommandFlag{{Name: "port", Type: common.FlagInt, Default: 80}},
+		}},
+	}
+	if err := reg.Register(meta); err != nil {
+		t.Fatal(err)
+	}
+	s := &ToolConfigService{Registry: reg}
+
+	tests := []struct {
+		path string
+		raw  string
+		want any
+	}{
+		{"plugin_depth", "2", 2},
+		{"plugin_dirs", "/a,/b", []any{"/a", "/b"}},
+		{"plugin_dirs[0]", "/a", "/a"},
+		{"executor.shell", "/bin/sh", "/bin/sh"},
+		{"plugins.sp.install.port", "8080", 8080},
+		// 未声明的插件 flag 按 YAML 推断
+		{"plugins.sp.install.other", "true", true},
+		{"plugins.unknown.install.x", "1", 1},
+	}
+	for _, tt := range tests {
+		got, err := s.coerce(tt.path, tt.raw)
+		if err != nil {
+			t.Errorf("coerce(%q, %q): %v", tt.path, tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("coerce(%q, %q) = %#v, want %#v", tt.path, tt.raw, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ path, raw, err string }{
+		{"plugin_depth", "deep", "invalid syntax"},
+		{"plugins.sp.install.port", "http", "flag 'port' expects int"},
+		{"plugin_dirs[x]", "/a", "invalid index"},
+	} {
+		if _, err := s.coerce(tt.path, tt.raw); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("coerce(%q, %q) error = %v, want %q", tt.path, tt.raw, err, tt.err)
+		}
+	}
+}
@@ -1,28 +1,25 @@
 package service
 
 import (
-	"os"
-	"path/filepath"
-
 	"github.com/spf13/cobra"
 
 	"github.com/bookandmusic/tool/internal/common"
 )
 
 type ToolInitService struct {
+	Runtime *common.Runtime
 }
 
 func (h *ToolInitService) cfg(args []string) error {
-	console := common.GlobalCfg.Logger
+	console := h.Runtime.Logger
 	var configPath string
 	if len(args) == 0 {
-		home, _ := os.UserHomeDir()
-		configPath = filepath.Join(home, ".config", "tool.yml")
+		configPath = common.DefaultUserConfigPath(h.Runtime.Env)
 	} else {
 		configPath = args[0]
 	}
 
-	defaultCfg := common.GenerateDefault()
+	defaultCfg := common.GenerateDefault(h.Runtime.Env)
 	if err := common.SaveConfig(configPath, defaultCfg); err != nil {
 		return err
 	}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+	"github.com/bookandmusic/tool/internal/utils"
+)
+
+// pluginInfo plugin info 的输出结构，json/yaml 格式直接序列化
+type pluginInfo struct {
+	Name         string        `json:"name" yaml:"name"`
+	Type         string        `json:"type" yaml:"type"`
+	Status       string        `json:"status" yaml:"status"`
+	Version      string        `json:"version" yaml:"version"`
+	Builtin      bool          `json:"builtin" yaml:"builtin"`
+	Description  string        `json:"description" yaml:"description"`
+	RequiresTool string        `json:"requires_tool,omitempty" yaml:"requires_tool,omitempty"`
+	Unsupported  string        `json:"unsupported,omitempty" yaml:"unsupported,omitempty"`
+	Dir          string        `json:"dir,omitempty" yaml:"dir,omitempty"`
+	Exec         string        `json:"exec,omitempty" yaml:"exec,omitempty"`
+	ExecType     string        `json:"exec_type,omitempty" yaml:"exec_type,omitempty"`
+	Executor     string        `json:"executor,omitempty" yaml:"executor,omitempty"`
+	Commands     []commandInfo `json:"commands" yaml:"commands"`
+}
+
+type commandInfo struct {
+	Name        string     `json:"name" yaml:"name"` // 顶层命令为空
+	Description string     `json:"description" yaml:"description"`
+	Flags       []flagInfo `json:"flags" yaml:"flags"`
+	// Ignored 配置中存在但命令未声明的 flag，执行时不会传给插件
+	Ignored []string `json:"ignored,omitempty" yaml:"ignored,omitempty"`
+	Argv    []string `json:"argv,omitempty" yaml:"argv,omitempty"`
+}
+
+type flagInfo struct {
+	Name       string `json:"name" yaml:"name"`
+	Type       string `json:"type" yaml:"type"`
+	Default    any    `json:"default" yaml:"default"`
+	Configured any    `json:"configured,omitempty" yaml:"configured,omitempty"` // cfg.Plugins 中的值
+	Effective  any    `json:"effective" yaml:"effective"`                       // 合并后实际传给插件的值
+}
+
+// configuredFlags 返回子命令在 cfg.Plugins 中配置的 flags，只有 soft 插件的 install/uninstall 有配置
+func configuredFlags(cfg *common.Config, meta *common.Meta, command string) map[string]any {
+	pc, ok := cfg.Plugins[meta.Name]
+	if !ok || meta.Type != common.Soft {
+		return nil
+	}
+	switch command {
+	case "install":
+		return pc.Install
+	case "uninstall":
+		return pc.Uninstall
+	}
+	return nil
+}
+
+// buildCommandInfo 按 MergeFlagsAndArgs 的规则计算每个 flag 的最终值：
+// 配置只覆盖命令声明过的 flag，未显式传入命令行参数
+func buildCommandInfo(rt *common.Runtime, meta *common.Meta, name, desc string, flags []*common.CommandFlag, resolve bool) (commandInfo, error) {
+	info := commandInfo{Name: name, Description: desc, Flags: []flagInfo{}}
+	configured := configuredFlags(rt.Cfg, meta, name)
+	kwargs := configured
+	if resolve {
+		var err error
+		if kwargs, err = resolveKwargs(rt, configured); err != nil {
+			return info, err
+		}
+	}
+	cmdParams := &common.CmdParams{Name: name, Flags: utils.CmdFlagsToMap(flags)}
+	effective := utils.MergeFlags(utils.CmdFlagsToMap(flags), kwargs, "cover")
+
+	for _, f := range flags {
+		fi := flagInfo{Name: f.Name, Type: utils.FlagKind(f), Default: f.Default, Effective: effective[f.Name]}
+		if v, ok := configured[f.Name]; ok {
+			fi.Configured = v
+		}
+		info.Flags = append(info.Flags, fi)
+	}
+	for key := range configured {
+		if _, ok := cmdParams.Flags[key]; !ok {
+			info.Ignored = append(info.Ignored, key)
+		}
+	}
+	sort.Strings(info.Ignored)
+
+	// 使用空命令构建参数，避免当前命令行的 flag 影响结果
+	if builder, ok := meta.Service.(common.CommandBuilder); ok {
+		argv, err := builder.BuildCommand(&cobra.Command{}, cmdParams, nil, kwargs)
+		if err != nil {
+			return info, err
+		}
+		info.Argv = argv
+	}
+	return info, nil
+}
+
+func buildPluginInfo(rt *common.Runtime, meta *common.Meta, resolve bool) (*pluginInfo, error) {
+	cfg := rt.Cfg
+	info := &pluginInfo{
+		Name:         meta.Name,
+		Type:         meta.Type.String(),
+		Status:       pluginStatus(meta, cfg),
+		Version:      pluginVersion(meta),
+		Builtin:      meta.BuiltIn,
+		Description:  meta.Desc,
+		RequiresTool: meta.RequiresTool,
+		Unsupported:  meta.Unsupported,
+		Exec:         meta.Exec,
+		ExecType:     meta.ExecType,
+		Commands:     []commandInfo{},
+	}
+	if meta.Dir != "" {
+		dir, err := filepath.Abs(meta.Dir)
+		if err != nil {
+			dir = meta.Dir
+		}
+		info.Dir = dir
+	}
+	if cfg.Executor != nil {
+		switch meta.ExecType {
+		case "shell":
+			info.Executor = cfg.Executor.Shell
+		case "python":
+			info.Executor = cfg.Executor.Python
+		}
+	}
+
+	// 只有外部插件或声明了 flags 的插件的顶层命令可以直接执行
+	if !meta.BuiltIn || len(meta.Flags) > 0 {
+		root, err := buildCommandInfo(rt, meta, "", meta.Desc, meta.Flags, resolve)
+		if err != nil {
+			return nil, err
+		}
+		info.Commands = append(info.Commands, root)
+	}
+	for _, c := range meta.Commands {
+		ci, err := buildCommandInfo(rt, meta, c.Name, c.Desc, c.Flags, resolve)
+		if err != nil {
+			return nil, err
+		}
+		info.Commands = append(info.Commands, ci)
+	}
+	return info, nil
+}
+
+func (s *ToolPluginService) info(cmd *cobra.Command, cmdParams *common.CmdParams, args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	if len(args) != 1 {
+		console.Error("Exactly one plugin name must be specified")
+		return fmt.Errorf("expected 1 plugin name, got %d", len(args))
+	}
+	meta := s.Registry.Get(args[0])
+	if meta == nil {
+		console.Error("Plugin '%s' not found%s", args[0], suggestPlugin(s.Registry, args[0], nil))
+		return fmt.Errorf("plugin '%s' not found", args[0])
+	}
+	flags, err := utils.MergeFlagsAndArgs(cmdParams.Flags, nil, cmd)
+	if err != nil {
+		return err
+	}
+	resolve, _ := flags["resolve"].(bool)
+
+	info, err := buildPluginInfo(s.Runtime, meta, resolve)
+	if err != nil {
+		console.Error("Failed to inspect plugin '%s': %v", meta.Name, err)
+		return err
+	}
+
+	switch s.Runtime.Output {
+	case common.OutputJSON:
+		data, err := marshalJSON(info, "  ")
+		if err != nil {
+			return err
+		}
+		console.Print(s.Runtime.Secrets.Mask(string(data)))
+		return nil
+	case common.OutputYAML:
+		data, err := yaml.Marshal(info)
+		if err != nil {
+			return err
+		}
+		console.Print(s.Runtime.Secrets.Mask(string(data)))
+		return nil
+	}
+	return renderPluginInfo(s.Runtime, console, info)
+}
+
+// renderPluginInfo 以文本形式输出插件信息，每个命令的 flags 以表格显示
+func renderPluginInfo(rt *common.Runtime, console logger.Logger, info *pluginInfo) error {
+	var sb strings.Builder
+	field := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "%-14s %s\n", key+":", rt.Secrets.Mask(value))
+		}
+	}
+	field("Name", info.Name)
+	field("Type", info.Type)
+	field("Status", info.Status)
+	field("Version", displayOptional(info.Version))
+	field("Builtin", fmt.Sprint(info.Builtin))
+	field("Description", info.Description)
+	field("Requires Tool", info.RequiresTool)
+	field("Unsupported", info.Unsupported)
+	field("Dir", info.Dir)
+	field("Exec", info.Exec)
+	field("Exec Type", info.ExecType)
+	field("Executor", info.Executor)
+	console.Print(sb.String())
+
+	for _, c := range info.Commands {
+		name := info.Name
+		if c.Name != "" {
+			name += " " + c.Name
+		}
+		console.Print(fmt.Sprintf("\nCommand: %s\n", name))
+		if len(c.Flags) > 0 {
+			listing := &Listing{Columns: []Column{
+				{Key: "flag", Header: "Flag"},
+				{Key: "type", Header: "Type"},
+				{Key: "default", Header: "Default"},
+				{Key: "configured", Header: "Configured", Display: displayOptional},
+				{Key: "effective", Header: "Effective"},
+			}}
+			for _, f := range c.Flags {
+				listing.AppendRow("--"+f.Name, f.Type, f.Default, f.Configured, rt.Secrets.Mask(fmt.Sprint(f.Effective)))
+			}
+			if err := listing.renderTable(console, listing.allColumns(), false, rt.Color); err != nil {
+				return err
+			}
+		}
+		if len(c.Ignored) > 0 {
+			console.Print(fmt.Sprintf("Ignored config: %s\n", strings.Join(c.Ignored, ", ")))
+		}
+		if len(c.Argv) > 0 {
+			console.Print(fmt.Sprintf("Argv: %s\n", rt.Secrets.Mask(strings.Join(c.Argv, " "))))
+		}
+	}
+	return nil
+}
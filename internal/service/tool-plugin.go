@@ -0,0 +1,356 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/lint"
+	"github.com/bookandmusic/tool/internal/logger"
+	"github.com/bookandmusic/tool/internal/plugins"
+	"github.com/bookandmusic/tool/internal/scaffold"
+	"github.com/bookandmusic/tool/internal/utils"
+)
+
+// ToolPluginService 插件管理命令（plugin ls 等），面向所有类型的插件
+type ToolPluginService struct {
+	Runtime  *common.Runtime
+	Registry *plugins.Registry
+}
+
+// pluginDetailColumns plugin ls 在 pluginColumns 之外的字段：
+//
+//	exec      插件执行的脚本
+//	exec_type 执行器：shell / python，直接执行时为空
+//	commands  子命令数量
+//	error     加载失败或被覆盖的原因，仅 status 为 failed / shadowed 时有值
+var pluginDetailColumns = append(append([]Column{}, pluginColumns...),
+	Column{Key: "exec", Header: "Exec", Display: displayOptional},
+	Column{Key: "exec_type", Header: "Exec Type", Display: displayOptional},
+	Column{Key: "commands", Header: "Commands"},
+	Column{Key: "error", Header: "Error", Display: displayOptional},
+)
+
+// pluginFilter plugin ls 的过滤条件，nil 表示不过滤
+type pluginFilter struct {
+	Type    string
+	Enabled *bool
+	BuiltIn *bool
+	Pattern string // 名称通配符，例如 dock*
+}
+
+func (f *pluginFilter) match(name, pluginType string, enabled, builtin bool) bool {
+	if f.Type != "" && f.Type != pluginType {
+		return false
+	}
+	// 只有 soft 插件有启用状态
+	if f.Enabled != nil && (pluginType != common.Soft.String() || *f.Enabled != enabled) {
+		return false
+	}
+	if f.BuiltIn != nil && *f.BuiltIn != builtin {
+		return false
+	}
+	if f.Pattern != "" {
+		if ok, _ := path.Match(f.Pattern, name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePluginFilter 根据命令行参数构造过滤条件，--enabled/--builtin 只在显式指定时生效
+func parsePluginFilter(cmd *cobra.Command, cmdParams *common.CmdParams, args []string) (*pluginFilter, error) {
+	flags, err := utils.MergeFlagsAndArgs(cmdParams.Flags, nil, cmd)
+	if err != nil {
+		return nil, err
+	}
+	filter := &pluginFilter{Type: fmt.Sprint(flags["type"])}
+	if filter.Type != "" {
+		if _, err := common.ParsePluginType(filter.Type); err != nil {
+			return nil, err
+		}
+	}
+	if cmd.Flags().Changed("enabled") {
+		enabled, _ := flags["enabled"].(bool)
+		filter.Enabled = &enabled
+	}
+	if cmd.Flags().Changed("builtin") {
+		builtin, _ := flags["builtin"].(bool)
+		filter.BuiltIn = &builtin
+	}
+	if len(args) > 0 {
+		filter.Pattern = args[0]
+		if _, err := path.Match(filter.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %w", filter.Pattern, err)
+		}
+	}
+	return filter, nil
+}
+
+func (s *ToolPluginService) list(cmd *cobra.Command, cmdParams *common.CmdParams, args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	cfg := s.Runtime.Cfg
+
+	filter, err := parsePluginFilter(cmd, cmdParams, args)
+	if err != nil {
+		console.Error("%v", err)
+		return err
+	}
+
+	listing := &Listing{
+		Columns: pluginDetailColumns,
+		Default: []string{"name", "type", "status", "version", "builtin", "commands", "dir"},
+	}
+	for _, meta := range s.Registry.List() {
+		enabled := meta.Type == common.Soft && slices.Contains(cfg.EnabledPlugins, meta.Name)
+		if !filter.match(meta.Name, meta.Type.String(), enabled, meta.BuiltIn) {
+			continue
+		}
+		row := append(pluginRow(meta, cfg), meta.Exec, meta.ExecType, len(meta.Commands), "")
+		listing.AppendRow(row...)
+	}
+
+	// 重名未生效的插件，error 中说明被哪个插件覆盖
+	for _, shadow := range s.Registry.Shadowed() {
+		meta := shadow.Meta
+		if !filter.match(meta.Name, meta.Type.String(), false, meta.BuiltIn) {
+			continue
+		}
+		by := shadow.By.Dir
+		switch {
+		case shadow.By.BuiltIn && shadow.By.Name != meta.Name:
+			// soft 插件与 soft 命令自身的子命令重名
+			by = fmt.Sprintf("builtin command '%s %s'", shadow.By.Name, meta.Name)
+		case shadow.By.BuiltIn:
+			by = "builtin plugin"
+		}
+		row := append(pluginRow(meta, cfg), meta.Exec, meta.ExecType, len(meta.Commands), "shadowed by "+by)
+		row[2] = StatusShadowed
+		listing.AppendRow(row...)
+		if !slices.Contains(listing.Default, "error") {
+			listing.Default = append(listing.Default, "error")
+		}
+	}
+
+	// 加载失败的插件类型未知，只参与名称过滤，不会被视为启用或内置
+	for _, f := range s.Registry.Failures() {
+		if filter.Type != "" || filter.Enabled != nil || (filter.BuiltIn != nil && *filter.BuiltIn) {
+			break
+		}
+		if !filter.match(f.Name, "", false, false) {
+			continue
+		}
+		listing.AppendRow(f.Name, "", StatusFailed, "", f.Dir, false, "", "", "", "", 0, f.Err.Error())
+		if !slices.Contains(listing.Default, "error") {
+			listing.Default = append(listing.Default, "error")
+		}
+	}
+	return listing.Render(s.Runtime, console)
+}
+
+// searchScore 插件与搜索词的匹配程度，越小越相关，-1 表示不匹配：
+// 名称相同 > 名称前缀 > 名称包含 > 描述包含 > 名称相近（编辑距离）
+func searchScore(meta *common.Meta, term string) int {
+	name := strings.ToLower(meta.Name)
+	switch {
+	case name == term:
+		return 0
+	case strings.HasPrefix(name, term):
+		return 1
+	case strings.Contains(name, term):
+		return 2
+	case strings.Contains(strings.ToLower(meta.Desc), term):
+		return 3
+	case utils.Levenshtein(name, term) <= utils.SuggestDistance(term):
+		return 4
+	}
+	return -1
+}
+
+func (s *ToolPluginService) search(args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	cfg := s.Runtime.Cfg
+	if len(args) == 0 {
+		console.Error("No search term specified")
+		return fmt.Errorf("no search term specified")
+	}
+	term := strings.ToLower(strings.Join(args, " "))
+
+	type match struct {
+		meta  *common.Meta
+		score int
+	}
+	var matches []match
+	for _, meta := range s.Registry.List() {
+		if score := searchScore(meta, term); score >= 0 {
+			matches = append(matches, match{meta, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].meta.Name < matches[j].meta.Name
+	})
+	if len(matches) == 0 {
+		console.Info("No plugins match '%s'", term)
+		return nil
+	}
+
+	listing := &Listing{
+		Columns: pluginColumns,
+		Default: []string{"name", "type", "status", "description"},
+	}
+	for _, m := range matches {
+		listing.AppendRow(pluginRow(m.meta, cfg)...)
+	}
+	return listing.Render(s.Runtime, console)
+}
+
+func (s *ToolPluginService) create(cmd *cobra.Command, cmdParams *common.CmdParams, args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	cfg := s.Runtime.Cfg
+
+	if len(args) != 1 {
+		console.Error("Exactly one plugin name must be specified")
+		return fmt.Errorf("expected 1 plugin name, got %d", len(args))
+	}
+	// 新插件生成在第一个不含通配符的插件目录下
+	baseDir := ""
+	for _, dir := range cfg.PluginDirs {
+		if !common.IsGlobPattern(dir) {
+			baseDir = dir
+			break
+		}
+	}
+	if baseDir == "" {
+		console.Error("No plugin_dirs without glob patterns configured")
+		return fmt.Errorf("no plugin_dirs without glob patterns configured")
+	}
+	if meta := s.Registry.Get(args[0]); meta != nil {
+		console.Error("Plugin '%s' already exists", args[0])
+		return fmt.Errorf("plugin '%s' already exists", args[0])
+	}
+
+	flags, err := utils.MergeFlagsAndArgs(cmdParams.Flags, nil, cmd)
+	if err != nil {
+		return err
+	}
+	opts := scaffold.Options{
+		Name: args[0],
+		Type: fmt.Sprint(flags["type"]),
+		Lang: fmt.Sprint(flags["lang"]),
+	}
+	// 新插件默认要求不低于当前工具的 minor 版本
+	if v, err := utils.ParseVersion(common.ToolVersion()); err == nil && len(v) >= 2 {
+		opts.RequiresTool = fmt.Sprintf(">=%d.%d", v[0], v[1])
+	}
+
+	dir, err := scaffold.Generate(common.ExpandHome(baseDir, s.Runtime.Env.HomeDir()), opts)
+	if err != nil {
+		console.Error("Failed to generate plugin '%s': %v", opts.Name, err)
+		return err
+	}
+	console.Success("Plugin '%s' generated at %s", opts.Name, dir)
+	return nil
+}
+
+func (s *ToolPluginService) lint(args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "lint")
+	cfg := s.Runtime.Cfg
+
+	paths := args
+	if len(paths) == 0 {
+		for _, dir := range common.ExpandPluginDirs(cfg.PluginDirs, s.Runtime.Env.HomeDir()) {
+			if _, err := os.Stat(dir); err == nil {
+				paths = append(paths, dir)
+			}
+		}
+	}
+	dirs, err := lint.Dirs(paths, cfg.PluginDepth)
+	if err != nil {
+		console.Error("%v", err)
+		return err
+	}
+
+	reserved := lint.Reserved{Builtins: map[string]bool{}, SoftCommands: s.Registry.SoftSubcommands()}
+	for _, meta := range s.Registry.List() {
+		if meta.BuiltIn {
+			reserved.Builtins[meta.Name] = true
+		}
+	}
+
+	var diags []lint.Diagnostic
+	for _, dir := range dirs {
+		diags = append(diags, lint.Dir(dir, reserved)...)
+	}
+	for _, d := range diags {
+		console.Print(d.String() + "\n")
+	}
+
+	errCount := lint.CountErrors(diags)
+	summary := fmt.Sprintf("%d plugin(s) checked, %d error(s), %d warning(s)", len(dirs), errCount, len(diags)-errCount)
+	if errCount > 0 {
+		console.Error("%s", summary)
+		return fmt.Errorf("lint found %d error(s)", errCount)
+	}
+	console.Success("%s", summary)
+	return nil
+}
+
+// cache 显示插件元数据缓存的位置，clear 时删除缓存，下次启动重新解析所有 meta.yml
+func (s *ToolPluginService) cache(args []string) error {
+	console := s.Runtime.Logger.With(logger.KeyComponent, "plugin")
+	path := s.Runtime.CachePath
+
+	if len(args) == 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			console.Info("No plugin cache at %s", path)
+			return nil
+		}
+		console.Info("Plugin cache: %s (%d bytes, updated %s)", path, info.Size(), info.ModTime().Format(time.DateTime))
+		return nil
+	}
+	if args[0] != "clear" {
+		console.Error("Unknown cache action '%s', expected 'clear'", args[0])
+		return fmt.Errorf("unknown cache action '%s'", args[0])
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			console.Info("Plugin cache is already empty")
+			return nil
+		}
+		console.Error("Failed to remove plugin cache %s: %v", path, err)
+		return err
+	}
+	console.Success("Plugin cache %s cleared", path)
+	return nil
+}
+
+func (s *ToolPluginService) Handler(cmd *cobra.Command, cmdParams *common.CmdParams, args []string, kwargs map[string]any) error {
+	switch cmdParams.Name {
+	case "":
+		return cmd.Help()
+	case "ls [pattern]":
+		return s.list(cmd, cmdParams, args)
+	case "info [name]":
+		return s.info(cmd, cmdParams, args)
+	case "search [term]":
+		return s.search(args)
+	case "new [name]":
+		return s.create(cmd, cmdParams, args)
+	case "lint [dir...]":
+		return s.lint(args)
+	case "cache [clear]":
+		return s.cache(args)
+	}
+	return nil
+}
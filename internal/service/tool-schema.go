@@ -0,0 +1,35 @@
+package service
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/schema"
+)
+
+// ToolSchemaService 输出 meta.yml / tool.yml 的 JSON Schema，供编辑器补全和校验使用
+type ToolSchemaService struct {
+	Runtime *common.Runtime
+}
+
+func (s *ToolSchemaService) print(sc *schema.Schema) error {
+	console := s.Runtime.Logger
+	data, err := sc.JSON()
+	if err != nil {
+		return err
+	}
+	console.Print(string(data) + "\n")
+	return nil
+}
+
+func (s *ToolSchemaService) Handler(cmd *cobra.Command, cmdParams *common.CmdParams, args []string, kwargs map[string]any) error {
+	switch cmdParams.Name {
+	case "":
+		return cmd.Help()
+	case "meta":
+		return s.print(common.MetaSchema())
+	case "config":
+		return s.print(common.ConfigSchema())
+	}
+	return nil
+}
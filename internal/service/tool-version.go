@@ -0,0 +1,19 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bookandmusic/tool/internal/common"
+)
+
+type ToolVersionService struct {
+	Runtime *common.Runtime
+}
+
+func (v *ToolVersionService) Handler(cmd *cobra.Command, cmdParams *common.CmdParams, args []string, kwargs map[string]any) error {
+	console := v.Runtime.Logger
+	console.Print(fmt.Sprintf("tool %s\n", common.ToolVersion()))
+	return nil
+}
@@ -1,85 +1,80 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
 
+	"github.com/bookandmusic/tool/internal/common"
 	"github.com/bookandmusic/tool/internal/logger"
 )
 
-func handlePipeOutput(r io.Reader, console logger.Logger) {
-	buf := make([]byte, 1024)
-	for {
-		n, err := r.Read(buf)
-		if n > 0 {
-			console.Debug(string(buf[:n]))
-		}
-		if err != nil {
-			break
-		}
+// buildEnv 在 environ（为 nil 时为当前进程的环境变量）之后追加 env
+func buildEnv(environ []string, env map[string]string) []string {
+	if environ == nil {
+		environ = os.Environ()
 	}
-}
-
-func buildEnv(env map[string]string) []string {
-	out := os.Environ()
+	out := append([]string(nil), environ...)
 	for k, v := range env {
 		out = append(out, fmt.Sprintf("%s=%s", k, v))
 	}
 	return out
 }
 
-func RunCommand(console logger.Logger, sudo bool, env map[string]string, workdir string, args ...string) error {
+// RunOptions 插件进程的运行参数
+type RunOptions struct {
+	Stdin   io.Reader         // 插件的标准输入
+	Sudo    bool              // 是否通过 sudo 执行
+	Environ []string          // 基础环境变量，nil 表示继承当前进程
+	Env     map[string]string // 追加在 Environ 之后的环境变量
+	Dir     string            // 工作目录，为空表示当前目录
+	Secrets *common.Secrets   // 日志中需要隐藏的机密值
+}
+
+// RunCommand 执行插件命令，ctx 取消时终止进程；
+// 标准输出和标准错误原样写入 console 的输出流和诊断流，只有日志中的命令和环境变量会隐藏机密值
+func RunCommand(ctx context.Context, console logger.Logger, opts RunOptions, args ...string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("no command provided")
 	}
-	cmdStr := strings.Join(args, " ")
-	console.Debug(fmt.Sprintf("[COMMAND] Executing command: %s\n", cmdStr))
-	console.Debug(fmt.Sprintf("[COMMAND] Working directory: %s\n", workdir))
+	env := opts.Env
+	console = console.With(logger.KeyComponent, "command")
+	cmdStr := opts.Secrets.Mask(strings.Join(args, " "))
+	console.Debug("Executing command: %s", cmdStr)
+	console.Trace("Working directory: %s", opts.Dir)
 	if env == nil {
-		console.Debug("[COMMAND] Environment variables: <nil>\n")
+		console.Trace("Environment variables: <nil>")
 	} else {
 		var envStrs []string
 		for k, v := range env {
 			envStrs = append(envStrs, fmt.Sprintf("%s=%s", k, v))
 		}
-		console.Debug(fmt.Sprintf("[COMMAND] Environment variables: %s\n", strings.Join(envStrs, " ")))
+		console.Trace("Environment variables: %s", opts.Secrets.Mask(strings.Join(envStrs, " ")))
 	}
 
 	cmdName := args[0]
 	cmdArgs := args[1:]
 
-	if sudo {
+	if opts.Sudo {
 		cmdArgs = append([]string{cmdName}, cmdArgs...)
 		cmdName = "sudo"
-		console.Debug("[COMMAND] Running with sudo\n")
+		console.Debug("Running with sudo")
 	}
 
-	cmd := exec.Command(cmdName, cmdArgs...) // #nosec G204
-	cmd.Stdin = os.Stdin
-	if workdir != "" {
-		cmd.Dir = workdir
+	cmd := exec.CommandContext(ctx, cmdName, cmdArgs...) // #nosec G204
+	cmd.Stdin = opts.Stdin
+	if opts.Dir != "" {
+		cmd.Dir = opts.Dir
 	}
-	if env != nil {
-		cmd.Env = buildEnv(env)
+	if opts.Environ != nil || env != nil {
+		cmd.Env = buildEnv(opts.Environ, env)
 	}
 
-	r, w := io.Pipe()
-	cmd.Stdout = w
-	cmd.Stderr = w
-
-	done := make(chan struct{})
-	go func() {
-		handlePipeOutput(r, console)
-		close(done)
-	}()
-
-	err := cmd.Run()
-	if cerr := w.Close(); cerr != nil {
-		console.Warning("[COMMAND] Failed to close pipe: %v\n", cerr)
-	}
-	<-done
-	return err
+	// 插件的标准输出作为命令结果写入 stdout，标准错误写入诊断流
+	cmd.Stdout = console.Writer()
+	cmd.Stderr = console.ErrWriter()
+	return cmd.Run()
 }
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bookandmusic/tool/internal/common"
+	"github.com/bookandmusic/tool/internal/logger"
+)
+
+func TestRunCommandKeepsPluginOutput(t *testing.T) {
+	secrets := common.NewSecrets()
+	secrets.Add("plugin-output-token")
+	var out, diag bytes.Buffer
+	console := logger.NewLogger(&out, &diag, logger.LevelDebug, &logger.TextFormatter{})
+	err := RunCommand(context.Background(), console, RunOptions{Secrets: secrets},
+		"sh", "-c", `echo "token=$1"; echo "warn $1" >&2`, "sh", "plugin-output-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "token=plugin-output-token\n" {
+		t.Errorf("stdout = %q, plugin output must not be rewritten", got)
+	}
+	if !strings.Contains(diag.String(), "warn plugin-output-token") {
+		t.Errorf("stderr = %q, want plugin stderr unchanged", diag.String())
+	}
+	if !strings.Contains(diag.String(), "Executing command: sh -c") || !strings.Contains(diag.String(), "sh ******") {
+		t.Errorf("debug log = %q, want the secret masked in the logged command", diag.String())
+	}
+}
+
+// promptWriter 在输出中出现 want 时关闭 seen
+type promptWriter struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	want string
+	seen chan struct{}
+	once sync.Once
+}
+
+func (w *promptWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	if strings.Contains(w.buf.String(), w.want) {
+		w.once.Do(func() { close(w.seen) })
+	}
+	return len(p), nil
+}
+
+func TestRunCommandShowsPromptBeforeInput(t *testing.T) {
+	out := &promptWriter{want: "Password: ", seen: make(chan struct{})}
+	console := logger.NewLogger(out, io.Discard, logger.LevelError, &logger.TextFormatter{})
+	stdinR, stdinW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- RunCommand(context.Background(), console, RunOptions{Stdin: stdinR},
+			"sh", "-c", `printf "Password: "; read answer; echo "got $answer"`)
+	}()
+
+	select {
+	case <-out.seen:
+	case <-time.After(5 * time.Second):
+		_ = stdinW.Close()
+		t.Fatal("prompt was not shown before input was given")
+	}
+	if _, err := io.WriteString(stdinW, "secret\n"); err != nil {
+		t.Fatal(err)
+	}
+	_ = stdinW.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if got := out.buf.String(); got != "Password: got secret\n" {
+		t.Errorf("output = %q", got)
+	}
+}
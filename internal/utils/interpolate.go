@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bookandmusic/tool/internal/common"
+)
+
+// maxInterpolationDepth ${config:...} 嵌套引用的最大深度，用于发现循环引用
+const maxInterpolationDepth = 10
+
+// Resolver 解析配置值中的 ${...} 表达式：
+//   - ${env:VAR}      环境变量，视为机密
+//   - ${config:a.b.c} 其他配置项（会继续解析其中的表达式）
+//   - ${file:/path}   文件内容，去掉末尾换行，视为机密
+//   - ${cmd:command}  通过 shell 执行命令的输出，去掉末尾换行，视为机密
+//
+// $${...} 表示字面量 ${...}
+type Resolver struct {
+	LookupConfig func(path string) (any, error)
+	Getenv       func(key string) (string, bool)
+	ReadFile     func(path string) ([]byte, error)
+	RunCommand   func(command string) (string, error)
+	Secrets      *common.Secrets // 登记 env/file/cmd 表达式的值，为 nil 时不登记
+}
+
+// NewResolver 创建读取 env 环境变量和真实文件系统的解析器，shell 用于执行 ${cmd:...}
+func NewResolver(shell string, env common.Environ, secrets *common.Secrets, lookup func(path string) (any, error)) *Resolver {
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	return &Resolver{
+		LookupConfig: lookup,
+		Getenv:       env.Lookup,
+		ReadFile: func(path string) ([]byte, error) {
+			return os.ReadFile(filepath.Clean(common.ExpandHome(path, env.HomeDir())))
+		},
+		RunCommand: func(command string) (string, error) {
+			cmd := exec.Command(shell, "-c", command) // #nosec G204 -- 命令来自用户自己的配置
+			cmd.Env = env.List()
+			out, err := cmd.Output()
+			return string(out), err
+		},
+		Secrets: secrets,
+	}
+}
+
+// ResolveMap 返回解析后的副本，只处理字符串值
+func (r *Resolver) ResolveMap(values map[string]any) (map[string]any, error) {
+	if values == nil {
+		return nil, nil
+	}
+	out := make(map[string]any, len(values))
+	for k, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			out[k] = v
+			continue
+		}
+		resolved, err := r.ResolveString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		out[k] = resolved
+	}
+	return out, nil
+}
+
+// ResolveString 解析字符串中的所有表达式
+func (r *Resolver) ResolveString(s string) (string, error) {
+	return r.resolve(s, 0)
+}
+
+func (r *Resolver) resolve(s string, depth int) (string, error) {
+	if depth > maxInterpolationDepth {
+		return "", fmt.Errorf("interpolation too deep, possible ${config:...} cycle")
+	}
+	var sb strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+		// $${...} 转义为字面量
+		if i > 0 && s[i-1] == '$' {
+			sb.WriteString(s[:i-1])
+			end := strings.Index(s[i:], "}")
+			if end < 0 {
+				sb.WriteString(s[i:])
+				return sb.String(), nil
+			}
+			sb.WriteString(s[i : i+end+1])
+			s = s[i+end+1:]
+			continue
+		}
+		end := strings.Index(s[i:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated expression in %q", s)
+		}
+		sb.WriteString(s[:i])
+		value, err := r.evaluate(s[i+2:i+end], depth)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(value)
+		s = s[i+end+1:]
+	}
+}
+
+func (r *Resolver) evaluate(expr string, depth int) (string, error) {
+	kind, arg, ok := strings.Cut(expr, ":")
+	if !ok || arg == "" {
+		return "", fmt.Errorf("invalid expression ${%s}, expected ${env|config|file|cmd:...}", expr)
+	}
+	switch kind {
+	case "env":
+		v, ok := r.Getenv(arg)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", arg)
+		}
+		return r.Secrets.Add(v), nil
+	case "config":
+		if r.LookupConfig == nil {
+			return "", fmt.Errorf("config lookup is not available for ${config:%s}", arg)
+		}
+		v, err := r.LookupConfig(arg)
+		if err != nil {
+			return "", err
+		}
+		return r.resolve(fmt.Sprint(v), depth+1)
+	case "file":
+		data, err := r.ReadFile(arg)
+		if err != nil {
+			return "", fmt.Errorf("read ${file:%s}: %w", arg, err)
+		}
+		return r.Secrets.Add(strings.TrimRight(string(data), "\r\n")), nil
+	case "cmd":
+		out, err := r.RunCommand(arg)
+		if err != nil {
+			return "", fmt.Errorf("run ${cmd:%s}: %w", arg, err)
+		}
+		return r.Secrets.Add(strings.TrimRight(out, "\r\n")), nil
+	default:
+		return "", fmt.Errorf("unknown expression type %q in ${%s}", kind, expr)
+	}
+}
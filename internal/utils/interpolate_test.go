@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bookandmusic/tool/internal/common"
+)
+
+func TestResolveRegistersSecrets(t *testing.T) {
+	r := &Resolver{
+		Getenv: func(key string) (string, bool) {
+			return map[string]string{"TOKEN": "env-token-value", "SHORT": "x"}[key], true
+		},
+		ReadFile:   func(string) ([]byte, error) { return []byte("file-secret\n"), nil },
+		RunCommand: func(string) (string, error) { return "cmd-secret\n", nil },
+		Secrets:    common.NewSecrets(),
+	}
+	values, err := r.ResolveMap(map[string]any{
+		"token": "${env:TOKEN}",
+		"short": "${env:SHORT}",
+		"file":  "${file:/secret}",
+		"cmd":   "${cmd:pass show x}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["token"] != "env-token-value" || values["short"] != "x" || values["file"] != "file-secret" || values["cmd"] != "cmd-secret" {
+		t.Fatalf("resolved = %v", values)
+	}
+
+	got := r.Secrets.Mask("--token=env-token-value --file=file-secret --cmd=cmd-secret --short=x")
+	if want := "--token=****** --file=****** --cmd=****** --short=x"; got != want {
+		t.Errorf("Mask() = %q, want %q", got, want)
+	}
+	if strings.Contains(r.Secrets.Mask("exit"), "*") {
+		t.Error("a one-character secret masks that character everywhere")
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/spf13/cobra"
@@ -70,9 +72,9 @@ func MergeFlagsAndArgs(flags, kwargs map[string]interface{}, cmd *cobra.Command)
 	cmdFlags := cmd.Flags()
 	flags = MergeFlags(flags, kwargs, "cover")
 
-	// 遍历所有的标志
+	// 遍历所有的标志，只有命令行显式指定的标志才覆盖默认值和配置
 	cmdFlags.VisitAll(func(f *pflag.Flag) {
-		if _, ok := flags[f.Name]; ok {
+		if _, ok := flags[f.Name]; ok && f.Changed {
 			var err error
 			switch f.Value.Type() {
 			case "string":
@@ -103,3 +105,80 @@ func MergeFlagsAndArgs(flags, kwargs map[string]interface{}, cmd *cobra.Command)
 
 	return flags, nil
 }
+
+// FlagKind 返回 flag 的类型：优先使用显式声明的 type，否则根据默认值推断
+func FlagKind(flag *common.CommandFlag) string {
+	if flag.Type != "" {
+		return flag.Type
+	}
+	switch flag.Default.(type) {
+	case bool:
+		return common.FlagBool
+	case int:
+		return common.FlagInt
+	case float64:
+		return common.FlagFloat
+	default:
+		return common.FlagString
+	}
+}
+
+// CoerceFlagValue 把命令行传入的字符串按 flag 类型转换
+func CoerceFlagValue(flag *common.CommandFlag, raw string) (any, error) {
+	switch FlagKind(flag) {
+	case common.FlagBool:
+		return strconv.ParseBool(raw)
+	case common.FlagInt:
+		return strconv.Atoi(raw)
+	case common.FlagFloat:
+		return strconv.ParseFloat(raw, 64)
+	default:
+		return raw, nil
+	}
+}
+
+// NormalizeFlagValue 把配置中的值按 flag 类型转换，用于比较 YAML 解码出的值与 meta 默认值，
+// 例如 int 类型的 1.0、"1" 与 1 相同；flag 为 nil 或无法转换时原样返回
+func NormalizeFlagValue(flag *common.CommandFlag, v any) any {
+	if flag == nil || v == nil {
+		return v
+	}
+	raw, ok := v.(string)
+	if !ok {
+		raw = fmt.Sprint(v)
+	}
+	switch FlagKind(flag) {
+	case common.FlagString:
+		return raw
+	case common.FlagFloat:
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f
+		}
+	case common.FlagInt:
+		if f, err := strconv.ParseFloat(raw, 64); err == nil && f == math.Trunc(f) {
+			return int(f)
+		}
+	case common.FlagBool:
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// FindFlag 在子命令和插件通用 flags 中查找 flag，子命令优先
+func FindFlag(meta *common.Meta, command, name string) *common.CommandFlag {
+	var scopes [][]*common.CommandFlag
+	if def := meta.GetCommand(command); def != nil {
+		scopes = append(scopes, def.Flags)
+	}
+	scopes = append(scopes, meta.Flags)
+	for _, flags := range scopes {
+		for _, f := range flags {
+			if f.Name == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
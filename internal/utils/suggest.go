@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Levenshtein 返回两个字符串的编辑距离（按 rune 计算）
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// SuggestDistance 输入长度对应的最大编辑距离：短名称允许 1-2 个字符的差异，长名称按比例放宽
+func SuggestDistance(input string) int {
+	return max(2, utf8.RuneCountInString(input)/3)
+}
+
+// Suggest 返回与 input 相近的候选项，按编辑距离从小到大排序，最多 limit 个（<=0 表示不限）
+// 候选项以 input 为前缀时也视为相近；比较不区分大小写
+func Suggest(input string, candidates []string, limit int) []string {
+	type scored struct {
+		name string
+		dist int
+	}
+	needle := strings.ToLower(input)
+	maxDist := SuggestDistance(input)
+	seen := map[string]bool{}
+	var matches []scored
+	for _, c := range candidates {
+		if c == input || seen[c] {
+			continue
+		}
+		seen[c] = true
+		lc := strings.ToLower(c)
+		d := Levenshtein(needle, lc)
+		if d > maxDist && !(needle != "" && strings.HasPrefix(lc, needle)) {
+			continue
+		}
+		matches = append(matches, scored{c, d})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].dist != matches[j].dist {
+			return matches[i].dist < matches[j].dist
+		}
+		return matches[i].name < matches[j].name
+	})
+	var out []string
+	for _, m := range matches {
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+		out = append(out, m.name)
+	}
+	return out
+}
+
+// DidYouMean 把建议格式化为 ", did you mean 'a' or 'b'?"，没有建议时返回空字符串
+func DidYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		quoted[i] = "'" + s + "'"
+	}
+	return ", did you mean " + strings.Join(quoted, " or ") + "?"
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// FlagChangeKind 三方合并中单个 flag 的处理结果
+type FlagChangeKind string
+
+const (
+	FlagAdded   FlagChangeKind = "added"   // 新增 flag，写入默认值
+	FlagRemoved FlagChangeKind = "removed" // flag 已删除且用户未修改，从配置中移除
+	FlagUpdated FlagChangeKind = "updated" // 默认值变化且用户未修改，更新为新默认值
+	FlagKept    FlagChangeKind = "kept"    // 用户修改过，保留用户的值
+)
+
+// FlagChange 三方合并的一条变更
+type FlagChange struct {
+	Key  string
+	Kind FlagChangeKind
+	Old  any // 配置中原来的值
+	New  any // 合并后的值（Kept 时为新的默认值）
+}
+
+func (c FlagChange) String() string {
+	switch c.Kind {
+	case FlagAdded:
+		return fmt.Sprintf("+ %s: %v", c.Key, c.New)
+	case FlagRemoved:
+		return fmt.Sprintf("- %s: %v", c.Key, c.Old)
+	case FlagUpdated:
+		return fmt.Sprintf("~ %s: %v -> %v", c.Key, c.Old, c.New)
+	default:
+		if c.New == nil {
+			return fmt.Sprintf("! %s: kept customized value %v (flag removed from meta)", c.Key, c.Old)
+		}
+		return fmt.Sprintf("! %s: kept customized value %v (default is now %v)", c.Key, c.Old, c.New)
+	}
+}
+
+// FlagNormalizer 把 key 对应的值转换为 flag 声明的类型，使不同写法的相同值可以比较
+type FlagNormalizer func(key string, v any) any
+
+// SameFlags 判断两组 flag 值是否相同，normalize 为 nil 时直接比较
+func SameFlags(a, b map[string]any, normalize FlagNormalizer) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		w, ok := b[k]
+		if !ok || !sameValue(normalize, k, v, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// ThreeWayMerge 以 base（启用时记录的默认值）为共同祖先，把 latest（当前 meta 默认值）
+// 的变化合并到 current（用户配置）中，只修改用户未改动过的值。
+// base 为 nil 表示没有记录，此时与最新默认值不同的值都视为用户修改过。
+// 值经 normalize 按 flag 类型转换后比较，normalize 为 nil 时直接比较。
+// 返回合并后的新 map 和按 key 排序的变更列表，current 不会被修改
+func ThreeWayMerge(base, current, latest map[string]any, normalize FlagNormalizer) (map[string]any, []FlagChange) {
+	merged := make(map[string]any, len(current))
+	for k, v := range current {
+		merged[k] = v
+	}
+	same := func(k string, a, b any) bool {
+		return sameValue(normalize, k, a, b)
+	}
+	customized := func(k string) bool {
+		if base == nil {
+			return !same(k, current[k], latest[k])
+		}
+		return !same(k, current[k], base[k])
+	}
+
+	var changes []FlagChange
+	for k, def := range latest {
+		cur, inCurrent := current[k]
+		_, inBase := base[k]
+		switch {
+		case !inCurrent && inBase:
+			// 用户主动删除了该 flag，不再补回
+		case !inCurrent:
+			merged[k] = def
+			changes = append(changes, FlagChange{Key: k, Kind: FlagAdded, New: def})
+		case same(k, cur, def):
+		case !customized(k):
+			merged[k] = def
+			changes = append(changes, FlagChange{Key: k, Kind: FlagUpdated, Old: cur, New: def})
+		case base == nil || !same(k, base[k], def):
+			// 用户修改过且默认值也变了，保留用户的值并提示
+			changes = append(changes, FlagChange{Key: k, Kind: FlagKept, Old: cur, New: def})
+		}
+	}
+	for k, cur := range current {
+		if _, ok := latest[k]; ok {
+			continue
+		}
+		if base != nil && !customized(k) {
+			delete(merged, k)
+			changes = append(changes, FlagChange{Key: k, Kind: FlagRemoved, Old: cur})
+		} else {
+			changes = append(changes, FlagChange{Key: k, Kind: FlagKept, Old: cur})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return merged, changes
+}
+
+// sameValue 经 normalize 转换后按类型和值比较
+func sameValue(normalize FlagNormalizer, key string, a, b any) bool {
+	if normalize != nil {
+		a, b = normalize(key, a), normalize(key, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bookandmusic/tool/internal/common"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        map[string]any
+		current     map[string]any
+		latest      map[string]any
+		wantMerged  map[string]any
+		wantChanges []FlagChange
+	}{
+		{
+			name:       "unchanged",
+			base:       map[string]any{"a": 1},
+			current:    map[string]any{"a": 1},
+			latest:     map[string]any{"a": 1},
+			wantMerged: map[string]any{"a": 1},
+		},
+		{
+			name:       "local change only",
+			base:       map[string]any{"a": 1},
+			current:    map[string]any{"a": 2},
+			latest:     map[string]any{"a": 1},
+			wantMerged: map[string]any{"a": 2},
+		},
+		{
+			name:        "upstream change only",
+			base:        map[string]any{"a": 1},
+			current:     map[string]any{"a": 1},
+			latest:      map[string]any{"a": 3},
+			wantMerged:  map[string]any{"a": 3},
+			wantChanges: []FlagChange{{Key: "a", Kind: FlagUpdated, Old: 1, New: 3}},
+		},
+		{
+			name:        "conflicting changes keep local",
+			base:        map[string]any{"a": 1},
+			current:     map[string]any{"a": 2},
+			latest:      map[string]any{"a": 3},
+			wantMerged:  map[string]any{"a": 2},
+			wantChanges: []FlagChange{{Key: "a", Kind: FlagKept, Old: 2, New: 3}},
+		},
+		{
+			name:       "same change on both sides",
+			base:       map[string]any{"a": 1},
+			current:    map[string]any{"a": 3},
+			latest:     map[string]any{"a": 3},
+			wantMerged: map[string]any{"a": 3},
+		},
+		{
+			name:        "added upstream",
+			base:        map[string]any{},
+			current:     map[string]any{},
+			latest:      map[string]any{"b": "x"},
+			wantMerged:  map[string]any{"b": "x"},
+			wantChanges: []FlagChange{{Key: "b", Kind: FlagAdded, New: "x"}},
+		},
+		{
+			name:       "deleted locally stays deleted",
+			base:       map[string]any{"a": 1},
+			current:    map[string]any{},
+			latest:     map[string]any{"a": 1},
+			wantMerged: map[string]any{},
+		},
+		{
+			name:        "deleted upstream",
+			base:        map[string]any{"a": 1},
+			current:     map[string]any{"a": 1},
+			latest:      map[string]any{},
+			wantMerged:  map[string]any{},
+			wantChanges: []FlagChange{{Key: "a", Kind: FlagRemoved, Old: 1}},
+		},
+		{
+			name:        "deleted upstream but customized",
+			base:        map[string]any{"a": 1},
+			current:     map[string]any{"a": 2},
+			latest:      map[string]any{},
+			wantMerged:  map[string]any{"a": 2},
+			wantChanges: []FlagChange{{Key: "a", Kind: FlagKept, Old: 2}},
+		},
+		{
+			name:        "no base treats differences as customized",
+			current:     map[string]any{"a": 2, "b": 1},
+			latest:      map[string]any{"a": 3, "b": 1},
+			wantMerged:  map[string]any{"a": 2, "b": 1},
+			wantChanges: []FlagChange{{Key: "a", Kind: FlagKept, Old: 2, New: 3}},
+		},
+		{
+			name:       "types differ without normalizer",
+			base:       map[string]any{"a": 1},
+			current:    map[string]any{"a": "1"},
+			latest:     map[string]any{"a": 1},
+			wantMerged: map[string]any{"a": "1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, changes := ThreeWayMerge(tt.base, tt.current, tt.latest, nil)
+			if !reflect.DeepEqual(merged, tt.wantMerged) {
+				t.Errorf("merged = %v, want %v", merged, tt.wantMerged)
+			}
+			if len(changes) != 0 || len(tt.wantChanges) != 0 {
+				if !reflect.DeepEqual(changes, tt.wantChanges) {
+					t.Errorf("changes = %+v, want %+v", changes, tt.wantChanges)
+				}
+			}
+		})
+	}
+}
+
+func TestThreeWayMergeNormalizesByFlagType(t *testing.T) {
+	flags := map[string]*common.CommandFlag{
+		"port":  {Name: "port", Type: common.FlagInt},
+		"ratio": {Name: "ratio", Type: common.FlagFloat},
+		"debug": {Name: "debug", Default: false},
+		"tag":   {Name: "tag", Type: common.FlagString},
+	}
+	normalize := func(key string, v any) any { return NormalizeFlagValue(flags[key], v) }
+
+	base := map[string]any{"port": 80, "ratio": 1, "debug": false, "tag": "1"}
+	current := map[string]any{"port": 80.0, "ratio": 1.0, "debug": "false", "tag": 1}
+	latest := map[string]any{"port": 8080, "ratio": 1.5, "debug": false, "tag": "1"}
+	merged, changes := ThreeWayMerge(base, current, latest, normalize)
+
+	want := map[string]any{"port": 8080, "ratio": 1.5, "debug": "false", "tag": 1}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("merged = %v, want %v", merged, want)
+	}
+	if len(changes) != 2 || changes[0].Key != "port" || changes[1].Key != "ratio" {
+		t.Errorf("changes = %+v, want port and ratio updated", changes)
+	}
+	for _, c := range changes {
+		if c.Kind != FlagUpdated {
+			t.Errorf("%s: kind = %s, want updated", c.Key, c.Kind)
+		}
+	}
+
+	// 不经过类型转换时 80.0 与 80 不同，会被误判为用户修改
+	if _, changes := ThreeWayMerge(base, current, latest, nil); changes[0].Kind != FlagKept {
+		t.Errorf("changes without normalizer = %+v", changes)
+	}
+	if !SameFlags(base, current, normalize) || SameFlags(base, latest, normalize) {
+		t.Error("SameFlags() did not compare normalized values")
+	}
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseVersion 解析形如 v1.2.3 / 1.2 / 0.3.0-rc1 的版本号，返回数字段
+// 预发布和构建元数据（- 或 + 之后的部分）会被忽略
+func ParseVersion(v string) ([]int, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" {
+		return nil, fmt.Errorf("invalid version: %q", v)
+	}
+	parts := strings.Split(s, ".")
+	nums := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version: %q", v)
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
+
+// CompareVersions 比较两个版本号，a<b 返回 -1，a==b 返回 0，a>b 返回 1
+// 缺失的段按 0 处理，即 0.3 == 0.3.0
+func CompareVersions(a, b string) (int, error) {
+	va, err := ParseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := ParseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// constraintOps 按长度降序排列，保证 ">=" 先于 ">" 匹配
+var constraintOps = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+// CheckVersionConstraint 判断 version 是否满足约束
+// 约束由逗号或空格分隔的多个条件组成，全部满足才算满足，例如 ">=0.3, <1.0"
+// 不带运算符的条件等价于 "=="
+func CheckVersionConstraint(version, constraint string) (bool, error) {
+	fields := strings.FieldsFunc(constraint, func(r rune) bool { return r == ',' || r == ' ' })
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		// 兼容 ">= 0.3" 这种运算符与版本号之间有空格的写法
+		if isConstraintOp(field) && i+1 < len(fields) {
+			i++
+			field += fields[i]
+		}
+		op, target := "==", field
+		for _, o := range constraintOps {
+			if strings.HasPrefix(field, o) {
+				op, target = o, strings.TrimSpace(field[len(o):])
+				break
+			}
+		}
+		c, err := CompareVersions(version, target)
+		if err != nil {
+			return false, err
+		}
+		var ok bool
+		switch op {
+		case ">=":
+			ok = c >= 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case "<":
+			ok = c < 0
+		case "!=":
+			ok = c != 0
+		default:
+			ok = c == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isConstraintOp(s string) bool {
+	for _, o := range constraintOps {
+		if s == o {
+			return true
+		}
+	}
+	return false
+}
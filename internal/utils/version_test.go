@@ -0,0 +1,80 @@
+package utils
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.3", "0.3.0", 0},
+		{"v0.3.1", "0.3", 1},
+		{"0.2.9", "0.3", -1},
+		{"1.10", "1.9", 1},
+		{"0.3.0-rc1", "0.3", 0},
+		{"6.8.0-45-generic", "6.8", 0},
+		{"1.0+build.1", "1.0.1", -1},
+	}
+	for _, tt := range tests {
+		got, err := CompareVersions(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("CompareVersions(%q, %q) error: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersionsInvalid(t *testing.T) {
+	for _, v := range []string{"", "v", "dev", "1.x", "1..2", "-1"} {
+		if _, err := CompareVersions(v, "1.0"); err == nil {
+			t.Errorf("CompareVersions(%q, 1.0) expected error", v)
+		}
+	}
+}
+
+func TestCheckVersionConstraint(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		{"0.3.0", ">=0.3", true},
+		{"0.2.5", ">=0.3", false},
+		{"v0.4.1", ">= 0.3", true},
+		{"0.3.0", ">0.3", false},
+		{"0.3.1", ">0.3", true},
+		{"0.9", "<1.0", true},
+		{"1.0", "<1.0", false},
+		{"1.0", "<=1.0", true},
+		{"0.5", ">=0.3, <1.0", true},
+		{"1.2", ">=0.3, <1.0", false},
+		{"0.5", ">=0.3 <1.0", true},
+		{"0.3", "0.3.0", true},
+		{"0.3", "=0.3", true},
+		{"0.3", "==0.4", false},
+		{"0.3", "!=0.3", false},
+		{"0.4", "!=0.3", true},
+		{"1.0.0", "", true},
+	}
+	for _, tt := range tests {
+		got, err := CheckVersionConstraint(tt.version, tt.constraint)
+		if err != nil {
+			t.Errorf("CheckVersionConstraint(%q, %q) error: %v", tt.version, tt.constraint, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CheckVersionConstraint(%q, %q) = %v, want %v", tt.version, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersionConstraintInvalid(t *testing.T) {
+	for _, c := range []string{">=abc", "~>1.0", ">="} {
+		if _, err := CheckVersionConstraint("1.0", c); err == nil {
+			t.Errorf("CheckVersionConstraint(1.0, %q) expected error", c)
+		}
+	}
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
 
 	"github.com/bookandmusic/tool/cmd"
 )
 
 func main() {
-	if err := cmd.Execute(); err != nil {
+	// Ctrl+C 时取消上下文，正在运行的插件进程随之终止
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := cmd.Execute(ctx)
+	stop()
+	if err != nil {
 		os.Exit(1)
 	}
 }
@@ -1,6 +1,7 @@
 #!/bin/bash
 
-VERSION="Tool v0.1.0"
+# 工具版本由 tool 在执行插件时通过 TOOL_VERSION 环境变量传入
+VERSION="Tool ${TOOL_VERSION:-unknown}"
 
 # 判断是否传入 --verbose
 VERBOSE=false